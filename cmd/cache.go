@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+)
+
+// cacheFileName is the name of the cache file inside --cache-dir.
+const cacheFileName = "sorted.json"
+
+// sortedCache remembers the files found already sorted, by size,
+// modification time and the options they were sorted with, so that later
+// runs can skip them without parsing. The whole cache is dropped when the
+// tool version changes.
+type sortedCache struct {
+	path string
+
+	mu    sync.Mutex
+	data  cacheData
+	dirty bool
+}
+
+type cacheData struct {
+	// Key identifies the tool version the files were checked with
+	Key   string                `json:"key"`
+	Files map[string]cacheEntry `json:"files"`
+}
+
+type cacheEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	// Options hashes the options the file is sorted under
+	Options string `json:"options"`
+}
+
+// openCache loads the cache for this run, or returns nil if caching is
+// off or cannot help: only modes that print nothing for a sorted file
+// can skip one, and some options depend on other files than the one
+// processed.
+func openCache() (*sortedCache, error) {
+	if noCache || packageMode || orderTests || jsonOutput || listOnly || verbose || detectDups || warnMixedRecv {
+		return nil, nil
+	}
+	if !checkOnly && !showDiff && (!writeInPlace || dryRun || outputPath != "") {
+		return nil, nil
+	}
+
+	dir := cacheDir
+	if dir == "" {
+		userDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, nil
+		}
+		dir = filepath.Join(userDir, "reordertool")
+	}
+	key := version()
+	c := &sortedCache{path: filepath.Join(dir, cacheFileName)}
+	raw, err := os.ReadFile(c.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read cache %s: %w", c.path, err)
+	}
+	// A corrupt cache is as good as none
+	if err != nil || json.Unmarshal(raw, &c.data) != nil || c.data.Key != key {
+		c.data = cacheData{Key: key}
+	}
+	if c.data.Files == nil {
+		c.data.Files = make(map[string]cacheEntry)
+	}
+	return c, nil
+}
+
+// optionsKey hashes everything in opts that decides the new order of a
+// file. It is empty if opts cannot be encoded.
+func optionsKey(opts reorder.Options) string {
+	// A compiled expression does not encode; its source does
+	var exclude string
+	if opts.Exclude != nil {
+		exclude = opts.Exclude.String()
+	}
+	opts.Exclude = nil
+	raw, err := json.Marshal(struct {
+		Exclude string
+		Options reorder.Options
+	}{exclude, opts})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// sorted reports whether path is known to be sorted under opts in its
+// current state.
+func (c *sortedCache) sorted(path string, opts reorder.Options) bool {
+	if c == nil {
+		return false
+	}
+	key := optionsKey(opts)
+	abs, info, ok := cacheStat(path)
+	if !ok {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.data.Files[abs]
+	return ok && key != "" && e.Options == key && e.Size == info.Size() && e.ModTime.Equal(info.ModTime())
+}
+
+// add records path as sorted under opts in its current state.
+func (c *sortedCache) add(path string, opts reorder.Options) {
+	if c == nil {
+		return
+	}
+	key := optionsKey(opts)
+	abs, info, ok := cacheStat(path)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data.Files[abs] = cacheEntry{Size: info.Size(), ModTime: info.ModTime(), Options: key}
+	c.dirty = true
+}
+
+func cacheStat(path string) (string, fs.FileInfo, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil, false
+	}
+	info, err := os.Stat(abs)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", nil, false
+	}
+	return abs, info, true
+}
+
+// save writes the cache back if anything was added.
+func (c *sortedCache) save() error {
+	if c == nil || !c.dirty {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	raw, err := json.Marshal(c.data)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := writeFileAtomic(c.path, raw, 0644); err != nil {
+		return fmt.Errorf("failed to write cache %s: %w", c.path, err)
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "Generate a shell completion script",
+	Long: `Generates a completion script for the given shell and prints it to
+stdout. For example, to load completions in the current bash session:
+
+  source <(reordertool completion bash)
+
+See your shell's documentation for loading them in every session.`,
+	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := os.Stdout
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		case "powershell":
+			return rootCmd.GenPowerShellCompletionWithDesc(out)
+		}
+		return fmt.Errorf("unsupported shell %q", args[0])
+	},
+}
+
+// registerCompletions sets up completion of the root command's arguments
+// and flags. It runs after the flags are defined.
+func registerCompletions() {
+	rootCmd.AddCommand(completionCmd)
+
+	// Paths complete to .go files and directories
+	rootCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"go"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+
+	rootCmd.MarkPersistentFlagFilename("config", "yaml", "yml")
+	rootCmd.MarkFlagFilename("interface-file", "go")
+	rootCmd.MarkFlagFilename("from-file")
+	rootCmd.MarkFlagFilename("output")
+	rootCmd.MarkFlagDirname("watch")
+	rootCmd.RegisterFlagCompletionFunc("sort", fixedCompletion(reorder.SortModes...))
+	rootCmd.RegisterFlagCompletionFunc("within-group", fixedCompletion(reorder.SortModes...))
+	rootCmd.RegisterFlagCompletionFunc("group-order", fixedCompletion(reorder.GroupOrders...))
+	rootCmd.RegisterFlagCompletionFunc("preset", fixedCompletion(presetNames()...))
+	rootCmd.RegisterFlagCompletionFunc("convention", fixedCompletion(reorder.ConventionStringerFirst))
+}
+
+// fixedCompletion completes a flag to one of values.
+func fixedCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
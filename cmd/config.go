@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is looked up in the directory of each processed file
+// and its parents when --config is not given.
+const defaultConfigFile = ".reordertool.yaml"
+
+// Config holds the settings of a config file. Apart from priority, the
+// keys mirror the command line flags of the same name. A flag given on
+// the command line wins over them; unset keys leave the flag default.
+type Config struct {
+	// Priority lists method names that sort to the top, in this order.
+	Priority []string `yaml:"priority"`
+
+	Sort             *string  `yaml:"sort"`
+	KeepPrefix       []string `yaml:"keep-prefix"`
+	Exclude          *string  `yaml:"exclude"`
+	GroupByReceiver  *bool    `yaml:"group-by-receiver"`
+	WithinGroup      *string  `yaml:"within-group"`
+	GroupOrder       *string  `yaml:"group-order"`
+	IncludeFunctions *bool    `yaml:"include-functions"`
+	DeprecatedLast   *bool    `yaml:"deprecated-last"`
+
+	// exclude is Exclude, compiled
+	exclude *regexp.Regexp
+}
+
+// loadConfig reads the config file at path.
+func loadConfig(path string) (Config, error) {
+	var cfg Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return cfg, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if cfg.Exclude != nil && *cfg.Exclude != "" {
+		cfg.exclude, err = regexp.Compile(*cfg.Exclude)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid exclude expression in config file %s: %w", path, err)
+		}
+	}
+	return cfg, nil
+}
+
+// merge returns c with every key set in near replacing its own.
+func (c Config) merge(near Config) Config {
+	if near.Priority != nil {
+		c.Priority = near.Priority
+	}
+	if near.Sort != nil {
+		c.Sort = near.Sort
+	}
+	if near.KeepPrefix != nil {
+		c.KeepPrefix = near.KeepPrefix
+	}
+	if near.Exclude != nil {
+		c.Exclude, c.exclude = near.Exclude, near.exclude
+	}
+	if near.GroupByReceiver != nil {
+		c.GroupByReceiver = near.GroupByReceiver
+	}
+	if near.WithinGroup != nil {
+		c.WithinGroup = near.WithinGroup
+	}
+	if near.GroupOrder != nil {
+		c.GroupOrder = near.GroupOrder
+	}
+	if near.IncludeFunctions != nil {
+		c.IncludeFunctions = near.IncludeFunctions
+	}
+	if near.DeprecatedLast != nil {
+		c.DeprecatedLast = near.DeprecatedLast
+	}
+	return c
+}
+
+// apply sets the options the config has keys for, unless the matching
+// flag was given on the command line.
+func (c Config) apply(opts *reorder.Options) {
+	if c.Sort != nil && !explicitFlags["sort"] {
+		opts.SortMode = *c.Sort
+	}
+	if c.KeepPrefix != nil && !explicitFlags["keep-prefix"] {
+		opts.KeepPrefixes = withoutNew(c.KeepPrefix)
+	}
+	if c.Exclude != nil && !explicitFlags["exclude"] {
+		opts.Exclude = c.exclude
+	}
+	if c.GroupByReceiver != nil && !explicitFlags["group-by-receiver"] {
+		opts.GroupByReceiver = *c.GroupByReceiver
+	}
+	if c.WithinGroup != nil && !explicitFlags["within-group"] {
+		opts.WithinGroup = *c.WithinGroup
+	}
+	if c.GroupOrder != nil && !explicitFlags["group-order"] {
+		opts.GroupOrder = *c.GroupOrder
+	}
+	if c.IncludeFunctions != nil && !explicitFlags["include-functions"] {
+		opts.IncludeFunctions = *c.IncludeFunctions
+	}
+	if c.DeprecatedLast != nil && !explicitFlags["deprecated-last"] {
+		opts.DeprecatedLast = *c.DeprecatedLast
+	}
+}
+
+// configFinder discovers the config files that apply to each processed
+// file. Results are cached per directory and shared between workers.
+type configFinder struct {
+	mu    sync.Mutex
+	cache map[string]*foundConfig
+}
+
+type foundConfig struct {
+	once sync.Once
+	cfg  Config
+	err  error
+}
+
+var configs = &configFinder{cache: make(map[string]*foundConfig)}
+
+// explicitFlags holds the names of the flags given on the command line,
+// which take precedence over config files.
+var explicitFlags map[string]bool
+
+// configFor returns the configuration for the file at path: the --config
+// file if one was given, otherwise the merge of the .reordertool.yaml
+// files in path's directory and its parents up to the repository root,
+// nearer files winning key by key.
+func configFor(path string) (Config, error) {
+	if configFile != "" {
+		return config, nil
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return Config{}, err
+	}
+	return configs.dir(dir)
+}
+
+func (f *configFinder) dir(dir string) (Config, error) {
+	f.mu.Lock()
+	found, ok := f.cache[dir]
+	if !ok {
+		found = &foundConfig{}
+		f.cache[dir] = found
+	}
+	f.mu.Unlock()
+
+	found.once.Do(func() {
+		// Start from the parent's merged config, unless dir is the
+		// repository or filesystem root
+		parent := filepath.Dir(dir)
+		if parent != dir && !isRepoRoot(dir) {
+			found.cfg, found.err = f.dir(parent)
+			if found.err != nil {
+				return
+			}
+		}
+
+		path := filepath.Join(dir, defaultConfigFile)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		near, err := loadConfig(path)
+		if err != nil {
+			found.err = err
+			return
+		}
+		found.cfg = found.cfg.merge(near)
+	})
+	return found.cfg, found.err
+}
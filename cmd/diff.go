@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns a unified diff between a and b, labelled with name.
+// It returns an empty string when the inputs are identical.
+func unifiedDiff(name string, a, b []byte) string {
+	ops := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	// Line positions in a and b before each op, for the hunk headers
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		start := max(0, i-diffContext)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+		stop := min(len(ops), end+diffContext+1)
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s.orig\n+++ %s\n", name, name)
+		}
+		aLen, bLen := aPos[stop]-aPos[start], bPos[stop]-bPos[start]
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aPos[start], aLen), hunkRange(bPos[start], bLen))
+		for _, op := range ops[start:stop] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffMaxCost bounds the number of edits diffLines searches from each
+// end of a range for the middle of its edit script. A range that needs
+// more than twice as many is given as a single replacement instead,
+// which keeps --diff fast on large files whose methods all move.
+const diffMaxCost = 4096
+
+// diffLines computes an edit script from a to b using the linear-space
+// variant of the Myers algorithm. The script is a shortest one, except
+// that ranges needing more than 2*diffMaxCost edits are replaced whole.
+func diffLines(a, b []string) []diffOp {
+	d := differ{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	return d.ops
+}
+
+type differ struct {
+	a, b []string
+	ops  []diffOp
+}
+
+// compare appends the edit script from a[aLo:aHi] to b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	// Common lines at either end are kept without searching
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, diffOp{' ', d.a[aLo]})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	if x, y, u, v, ok := d.middleSnake(aLo, aHi, bLo, bHi); ok {
+		d.compare(aLo, x, bLo, y)
+		for _, line := range d.a[x:u] {
+			d.ops = append(d.ops, diffOp{' ', line})
+		}
+		d.compare(u, aHi, v, bHi)
+	} else {
+		for _, line := range d.a[aLo:aHi] {
+			d.ops = append(d.ops, diffOp{'-', line})
+		}
+		for _, line := range d.b[bLo:bHi] {
+			d.ops = append(d.ops, diffOp{'+', line})
+		}
+	}
+
+	for _, line := range d.a[aHi : aHi+suffix] {
+		d.ops = append(d.ops, diffOp{' ', line})
+	}
+}
+
+// middleSnake finds the middle snake of a shortest edit script from
+// a[aLo:aHi] to b[bLo:bHi], which runs from (x, y) to (u, v). The ranges
+// must differ in their first and last lines. ok is false if either range
+// is empty, leaving nothing to split, or if the script needs more than
+// 2*diffMaxCost edits.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int, ok bool) {
+	n, m := aHi-aLo, bHi-bLo
+	if n == 0 || m == 0 {
+		return 0, 0, 0, 0, false
+	}
+	delta := n - m
+	odd := delta%2 != 0
+	half := (n + m + 1) / 2
+	off := half + 1
+
+	// fwd and bwd hold the furthest x reached on each diagonal from the
+	// start and, counting backwards, from the end
+	fwd := make([]int, 2*off+1)
+	bwd := make([]int, 2*off+1)
+	for cost := 0; cost <= min(half, diffMaxCost); cost++ {
+		for k := -cost; k <= cost; k += 2 {
+			if k == -cost || k != cost && fwd[off+k-1] < fwd[off+k+1] {
+				x = fwd[off+k+1]
+			} else {
+				x = fwd[off+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && d.a[aLo+u] == d.b[bLo+v] {
+				u++
+				v++
+			}
+			fwd[off+k] = u
+			if odd && k >= delta-(cost-1) && k <= delta+(cost-1) && u+bwd[off+delta-k] >= n {
+				return aLo + x, bLo + y, aLo + u, bLo + v, true
+			}
+		}
+		for k := -cost; k <= cost; k += 2 {
+			if k == -cost || k != cost && bwd[off+k-1] < bwd[off+k+1] {
+				x = bwd[off+k+1]
+			} else {
+				x = bwd[off+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && d.a[aHi-1-u] == d.b[bHi-1-v] {
+				u++
+				v++
+			}
+			bwd[off+k] = u
+			if !odd && k >= delta-cost && k <= delta+cost && u+fwd[off+delta-k] >= n {
+				return aHi - u, bHi - v, aHi - x, bHi - y, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
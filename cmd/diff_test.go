@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "swap",
+			a:    "a\nb\nc\n",
+			b:    "b\na\nc\n",
+			want: "--- f.go.orig\n+++ f.go\n@@ -1,3 +1,3 @@\n-a\n b\n+a\n c\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "0\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n13\n",
+			want: "--- f.go.orig\n+++ f.go\n@@ -1,4 +1,4 @@\n-1\n+0\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+13\n",
+		},
+		{
+			name: "no final newline",
+			a:    "a\nb",
+			b:    "a\nc",
+			want: "--- f.go.orig\n+++ f.go\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "a\n",
+			want: "--- f.go.orig\n+++ f.go\n@@ -0,0 +1 @@\n+a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f.go", []byte(tt.a), []byte(tt.b)); got != tt.want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(rng), randomLines(rng)
+		ops := diffLines(a, b)
+
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.line)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("diffLines(%q, %q) does not turn one into the other: %v", a, b, ops)
+		}
+		if want := len(a) + len(b) - 2*lcsLen(a, b); edits != want {
+			t.Fatalf("diffLines(%q, %q) makes %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesMaxCost(t *testing.T) {
+	// Reversed, all but one line must be removed and added again, which
+	// is more edits than the search allows, so the range is replaced whole
+	const n = 3 * diffMaxCost
+	var a, b []string
+	for i := 0; i < n; i++ {
+		a = append(a, fmt.Sprintf("%d\n", i))
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		b = append(b, a[i])
+	}
+	a = append([]string{"same\n"}, a...)
+	b = append([]string{"same\n"}, b...)
+
+	ops := diffLines(a, b)
+	if len(ops) != 1+2*n {
+		t.Fatalf("diffLines() returns %d ops, want %d", len(ops), 1+2*n)
+	}
+	if ops[0] != (diffOp{' ', "same\n"}) {
+		t.Errorf("diffLines() does not keep the common first line")
+	}
+	for i, op := range ops[1:] {
+		var want diffOp
+		if i < n {
+			want = diffOp{'-', a[1+i]}
+		} else {
+			want = diffOp{'+', b[1+i-n]}
+		}
+		if op != want {
+			t.Fatalf("op %d is %v, want %v", i+1, op, want)
+		}
+	}
+}
+
+func randomLines(rng *rand.Rand) []string {
+	lines := make([]string, rng.Intn(12))
+	for i := range lines {
+		lines[i] = string(rune('a'+rng.Intn(4))) + "\n"
+	}
+	return lines
+}
+
+// lcsLen returns the length of the longest common subsequence of a and b.
+func lcsLen(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestMinimalDiffIsSmaller(t *testing.T) {
+	// Sorted except for the last method, which comes first. The const
+	// declarations between the methods stay in place, so the default
+	// reassembly moves every method past one of them.
+	var sb strings.Builder
+	sb.WriteString("package p\n\ntype T struct{}\n")
+	for i, name := range []string{"Z", "A", "B", "C", "D", "E", "F"} {
+		fmt.Fprintf(&sb, "\n// %s does %s things.\nfunc (T) %s() string {\n\treturn %q\n}\n", name, name, name, name)
+		fmt.Fprintf(&sb, "\nconst c%d = %d\n", i, i)
+	}
+	src := []byte(sb.String())
+
+	changed := func(minimal bool) (int, []string) {
+		opts := reorder.DefaultOptions()
+		opts.MinimalDiff = minimal
+		res, err := reorder.Process(src, "t.go", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var order []string
+		for _, m := range res.Moves {
+			order = append(order, m.Method)
+		}
+		n := 0
+		for _, op := range diffLines(splitLines(string(src)), splitLines(string(res.Output))) {
+			if op.kind != ' ' {
+				n++
+			}
+		}
+		return n, order
+	}
+	full, fullOrder := changed(false)
+	minimal, minimalOrder := changed(true)
+	if !slices.Equal(fullOrder, minimalOrder) {
+		t.Errorf("--minimal-diff order is %v, want %v", minimalOrder, fullOrder)
+	}
+	// Only Z is taken out and put back at the end
+	if want := 2 * 5; minimal != want {
+		t.Errorf("--minimal-diff changes %d lines, want %d", minimal, want)
+	}
+	if minimal >= full {
+		t.Errorf("--minimal-diff changes %d lines, no fewer than the %d of the default", minimal, full)
+	}
+}
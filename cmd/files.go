@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// collectFiles expands the command arguments into the list of files to
+// process. Directories are walked recursively for .go files, skipping
+// paths ignored by .gitignore when --respect-gitignore is set. Errors are
+// collected rather than returned early so that the remaining arguments
+// are still processed.
+func collectFiles(args []string) ([]string, []error) {
+	var files []string
+	var errs []error
+
+	var ignore *gitignore
+	if respectGitignore {
+		ignore = newGitignore()
+	}
+
+	for _, arg := range args {
+		if arg == "-" {
+			files = append(files, arg)
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to stat %s: %w", arg, err))
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to walk %s: %w", path, err))
+				return nil
+			}
+			if path != arg && ignore != nil && ignore.ignored(path, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if path != arg && skipDir(d.Name()) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") {
+				return nil
+			}
+			// Named test files are processed, walked ones only on request
+			if !includeTests && strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to walk %s: %w", arg, err))
+		}
+	}
+
+	return files, errs
+}
+
+// readFileList reads the paths listed in path, one per line. Blank lines
+// and lines starting with "#" are ignored.
+func readFileList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file list %s: %w", path, err)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, nil
+}
+
+// skipDir reports whether a directory found while walking is left out,
+// as the go tool leaves out vendor, testdata and directories starting
+// with a dot or an underscore.
+func skipDir(name string) bool {
+	return name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// writeFileAtomic replaces the file at path with data. The data is
+// written to a temporary file in the same directory, which is then
+// renamed over path, so an interrupted run never leaves a truncated
+// file behind. Symbolic links are followed, so the link itself stays.
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) (err error) {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
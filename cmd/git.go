@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// changedFiles returns the .go files that differ between ref and the
+// working tree, relative to the current directory. Deleted files are left
+// out, and so are _test.go files unless --include-tests is set.
+func changedFiles(ref string) ([]string, error) {
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("--since needs a git repository: %w", err)
+	}
+	root := strings.TrimSpace(top)
+
+	out, err := git("diff", "--name-only", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files changed since %s: %w", ref, err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(out, "\n") {
+		if !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if !includeTests && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			path = rel
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// git runs git with args and returns its standard output. A failure is
+// reported with the first line git printed on standard error.
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.Command("git", args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// cleanChecker tells whether files have uncommitted changes, for
+// --require-clean. git is asked once per directory and the answers are
+// shared between workers.
+type cleanChecker struct {
+	mu    sync.Mutex
+	cache map[string]*dirtyDir
+}
+
+type dirtyDir struct {
+	once  sync.Once
+	paths map[string]bool
+}
+
+var cleanliness = &cleanChecker{cache: make(map[string]*dirtyDir)}
+
+// dirty reports whether git shows uncommitted changes, staged or not, to
+// the file at path. Untracked files and files outside a git repository
+// count as clean.
+func (c *cleanChecker) dirty(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+	dir := filepath.Dir(abs)
+
+	c.mu.Lock()
+	d, ok := c.cache[dir]
+	if !ok {
+		d = &dirtyDir{}
+		c.cache[dir] = d
+	}
+	c.mu.Unlock()
+
+	d.once.Do(func() { d.paths = modifiedIn(dir) })
+	return d.paths[abs]
+}
+
+// modifiedIn returns the absolute paths of the modified tracked files in
+// dir, or nil if dir is not in a git repository.
+func modifiedIn(dir string) map[string]bool {
+	top, err := git("-C", dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil
+	}
+	root := strings.TrimSpace(top)
+	out, err := git("-C", dir, "status", "--porcelain", "-z", "--untracked-files=no", "--", ".")
+	if err != nil {
+		return nil
+	}
+
+	paths := make(map[string]bool)
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		paths[filepath.Join(root, filepath.FromSlash(entry[3:]))] = true
+		// A rename or copy is followed by the original path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	return paths
+}
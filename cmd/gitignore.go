@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gitignore answers whether paths are ignored by the .gitignore files in
+// their directory and its parents, up to the root of the git repository.
+// Parsed files are cached per directory.
+type gitignore struct {
+	rules map[string][]ignoreRule
+}
+
+type ignoreRule struct {
+	base     string // directory holding the .gitignore
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func newGitignore() *gitignore {
+	return &gitignore{rules: make(map[string][]ignoreRule)}
+}
+
+// ignored reports whether path is ignored. Rules from deeper .gitignore
+// files come later and, as in git, the last matching rule wins.
+func (g *gitignore) ignored(p string, isDir bool) bool {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return false
+	}
+
+	result := false
+	for _, rule := range g.rulesFor(filepath.Dir(abs)) {
+		if rule.matches(abs, isDir) {
+			result = !rule.negate
+		}
+	}
+	return result
+}
+
+// rulesFor returns the rules that apply to entries of dir, outermost first.
+func (g *gitignore) rulesFor(dir string) []ignoreRule {
+	if rules, ok := g.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	parent := filepath.Dir(dir)
+	if parent != dir && !isRepoRoot(dir) {
+		rules = append(rules, g.rulesFor(parent)...)
+	}
+	rules = append(rules, readGitignore(dir)...)
+
+	g.rules[dir] = rules
+	return rules
+}
+
+func isRepoRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+func readGitignore(dir string) []ignoreRule {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// A slash anywhere but at the end anchors the pattern to base
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (r ignoreRule) matches(abs string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	rel, err := filepath.Rel(r.base, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	if !r.anchored {
+		return matchSegments([]string{r.pattern}, []string{path.Base(rel)})
+	}
+	return matchSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment matches any number of path segments.
+func matchSegments(pattern, segs []string) bool {
+	if len(pattern) == 0 {
+		return len(segs) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pattern[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], segs[0])
+	return err == nil && ok && matchSegments(pattern[1:], segs[1:])
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+)
+
+// grouping reports whether opts keep methods grouped by receiver.
+func grouping(opts reorder.Options) bool {
+	return opts.GroupByReceiver || opts.MatchInterface != "" || opts.IncludeFunctions && opts.GroupConstructors
+}
+
+// withGroupHeaders returns src with a "// --- T ---" comment line before
+// the first method of each run of methods of the same receiver type T.
+// It marks the receiver groups in the proposed side of --dry-run --diff
+// and is never written to a file.
+func withGroupHeaders(src []byte) []byte {
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return src
+	}
+
+	var b bytes.Buffer
+	off, prev := 0, ""
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil {
+			prev = ""
+			continue
+		}
+		recv := reorder.ReceiverType(funcDecl.Recv)
+		if recv == prev {
+			continue
+		}
+		prev = recv
+
+		start := funcDecl.Pos()
+		if funcDecl.Doc != nil {
+			start = funcDecl.Doc.Pos()
+		}
+		// Headers go on a line of their own, so start at column 1
+		lineStart := fSet.Position(start).Offset - (fSet.Position(start).Column - 1)
+		b.Write(src[off:lineStart])
+		b.WriteString("// --- " + recv + " ---\n")
+		off = lineStart
+	}
+	b.Write(src[off:])
+	return b.Bytes()
+}
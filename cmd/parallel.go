@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// fileOutput holds what processing a single file produced.
+type fileOutput struct {
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+	moves  []moveRecord
+	// finding is the --sarif result for an unsorted file
+	finding *sarifResult
+	// changed reports whether reordering altered the source, whether or
+	// not the result was written.
+	changed bool
+	// methods and moved are the method counts reported by reorder
+	methods, moved int
+	err            error
+}
+
+// processAll runs processFile over files with up to --max-procs workers.
+// report is called for each file in input order, as soon as that file
+// and all files before it are done, so output is never interleaved.
+// Processing stops early when report returns false.
+func processAll(files []string, multi bool, report func(path string, w *fileOutput) bool) {
+	results := make([]chan *fileOutput, len(files))
+	for i := range results {
+		results[i] = make(chan *fileOutput, 1)
+	}
+
+	jobs := make(chan int)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range max(maxProcs, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				w := &fileOutput{}
+				w.err = safeProcessFile(files[i], multi, w)
+				results[i] <- w
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for i, path := range files {
+		if !report(path, <-results[i]) {
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+// safeProcessFile is processFile, with a panic turned into an error for
+// that file so that the rest of the batch still runs. The stack trace is
+// included with --verbose.
+func safeProcessFile(path string, multi bool, w *fileOutput) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		err = fmt.Errorf("panic while processing %s (this is a bug): %v", path, r)
+		if verbose {
+			err = fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(debug.Stack())))
+		}
+	}()
+	return processFile(path, multi, w)
+}
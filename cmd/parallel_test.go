@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+)
+
+func TestPanicIsPerFile(t *testing.T) {
+	// A comparator that panics on one method stands in for a bug
+	reorder.RegisterComparator("test-panic", func(a, b reorder.Method) bool {
+		if a.Name() == "Boom" || b.Name() == "Boom" {
+			panic("boom")
+		}
+		return a.Name() < b.Name()
+	})
+
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "a_bad.go")
+	good := filepath.Join(dir, "b_good.go")
+	if err := os.WriteFile(bad, []byte("package p\n\ntype T struct{}\n\nfunc (T) Boom() {}\n\nfunc (T) A() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, procs := range []string{"1", "4"} {
+		if err := os.WriteFile(good, []byte(unsortedSrc), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := executeErr(t, "--sort=custom:test-panic", "--max-procs="+procs, "-w", bad, good)
+		if code := exitCode(err); code != ExitError {
+			t.Errorf("--max-procs=%s: exit code = %d, want %d", procs, code, ExitError)
+		}
+		if err == nil || !strings.Contains(err.Error(), "panic while processing "+bad) {
+			t.Errorf("--max-procs=%s: error = %v, want one naming %s", procs, err, bad)
+		}
+		if data, _ := os.ReadFile(good); string(data) != sortedSrc {
+			t.Errorf("--max-procs=%s: file after the panic not sorted:\n%s", procs, data)
+		}
+	}
+
+	// --verbose adds the stack
+	err := executeErr(t, "--sort=custom:test-panic", "--verbose", "--check", bad)
+	if err == nil || !strings.Contains(err.Error(), "goroutine ") {
+		t.Errorf("--verbose: error = %v, want one with the stack", err)
+	}
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+	"golang.org/x/tools/go/packages"
+)
+
+// packageLoader loads the packages enclosing processed files for
+// --package-mode. Loaded packages are cached per directory and shared
+// between workers.
+type packageLoader struct {
+	mu    sync.Mutex
+	cache map[string]*loadedDir
+}
+
+type loadedDir struct {
+	once sync.Once
+	pkgs []*packages.Package
+	err  error
+}
+
+var loader = &packageLoader{cache: make(map[string]*loadedDir)}
+
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedImports | packages.NeedDeps | packages.NeedTypes
+
+// info returns the type information reorder needs to process the file at
+// path with opts.
+func (l *packageLoader) info(path string, opts reorder.Options) (*reorder.PackageInfo, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := l.packageOf(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &reorder.PackageInfo{}
+	if opts.MatchInterface != "" {
+		iface, err := lookupInterface(pkg, opts.MatchInterface)
+		if err != nil {
+			return nil, err
+		}
+		info.InterfaceMethods = interfaceMethods(iface)
+		info.Implementers = implementers(pkg, iface)
+	}
+	if opts.GroupOrder == reorder.GroupOrderDeclaration {
+		info.TypeOrder = typeOrder(pkg)
+	}
+	if opts.SortMode == reorder.SortByFieldOrder || opts.WithinGroup == reorder.SortByFieldOrder {
+		info.Fields = structFields(pkg)
+	}
+	return info, nil
+}
+
+// packageOf returns the package, including test variants, that compiles
+// the file abs.
+func (l *packageLoader) packageOf(abs string) (*packages.Package, error) {
+	dir := filepath.Dir(abs)
+
+	l.mu.Lock()
+	d, ok := l.cache[dir]
+	if !ok {
+		d = &loadedDir{}
+		l.cache[dir] = d
+	}
+	l.mu.Unlock()
+
+	d.once.Do(func() {
+		cfg := &packages.Config{Mode: loadMode, Dir: dir, Tests: true}
+		d.pkgs, d.err = packages.Load(cfg, ".")
+		if d.err == nil && packages.PrintErrors(d.pkgs) > 0 {
+			d.err = fmt.Errorf("package in %s has errors", dir)
+		}
+	})
+	if d.err != nil {
+		return nil, fmt.Errorf("failed to load package for %s: %w", abs, d.err)
+	}
+
+	// Test variants also contain the non-test files; prefer the plain
+	// package unless abs is a test file
+	var found *packages.Package
+	for _, pkg := range d.pkgs {
+		if !slices.Contains(pkg.CompiledGoFiles, abs) {
+			continue
+		}
+		if found == nil || (strings.HasSuffix(abs, "_test.go") == strings.HasSuffix(pkg.ID, ".test]")) {
+			found = pkg
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no package found for %s", abs)
+	}
+	return found, nil
+}
+
+// lookupInterface resolves name, either "Name" in pkg or "path.Name" in
+// pkg or one of its dependencies.
+func lookupInterface(pkg *packages.Package, name string) (*types.Interface, error) {
+	target, ident := pkg.Types, name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		path := name[:i]
+		ident = name[i+1:]
+		target = findPackage(pkg, path)
+		if target == nil {
+			return nil, fmt.Errorf("interface %s not found: package %s is not a dependency of %s", name, path, pkg.PkgPath)
+		}
+	}
+
+	obj := target.Scope().Lookup(ident)
+	if obj == nil {
+		return nil, fmt.Errorf("interface %s not found", name)
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an interface", name)
+	}
+	return iface, nil
+}
+
+func findPackage(pkg *packages.Package, path string) *types.Package {
+	var found *types.Package
+	seen := make(map[*packages.Package]bool)
+	var walk func(*packages.Package)
+	walk = func(p *packages.Package) {
+		if found != nil || seen[p] {
+			return
+		}
+		seen[p] = true
+		if p.PkgPath == path || p.Types != nil && p.Types.Name() == path {
+			found = p.Types
+			return
+		}
+		for _, imp := range p.Imports {
+			walk(imp)
+		}
+	}
+	walk(pkg)
+	return found
+}
+
+// interfaceMethods returns the methods of iface in declaration order:
+// explicit methods by source position, then those of each embedded
+// interface.
+func interfaceMethods(iface *types.Interface) []string {
+	explicit := make([]*types.Func, iface.NumExplicitMethods())
+	for i := range explicit {
+		explicit[i] = iface.ExplicitMethod(i)
+	}
+	slices.SortStableFunc(explicit, func(a, b *types.Func) int {
+		return int(a.Pos() - b.Pos())
+	})
+
+	var names []string
+	for _, m := range explicit {
+		names = append(names, m.Name())
+	}
+	for i := range iface.NumEmbeddeds() {
+		if embedded, ok := iface.EmbeddedType(i).Underlying().(*types.Interface); ok {
+			for _, name := range interfaceMethods(embedded) {
+				if !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// implementers returns the names of the types declared in pkg whose
+// pointer type implements iface.
+func implementers(pkg *packages.Package, iface *types.Interface) map[string]bool {
+	names := make(map[string]bool)
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || types.IsInterface(tn.Type()) {
+			continue
+		}
+		if types.Implements(types.NewPointer(tn.Type()), iface) {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// typeOrder returns the names of the types declared in pkg, ordered by
+// file and then by position within the file.
+func typeOrder(pkg *packages.Package) []string {
+	var objs []*types.TypeName
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		if tn, ok := scope.Lookup(name).(*types.TypeName); ok {
+			objs = append(objs, tn)
+		}
+	}
+	slices.SortFunc(objs, func(a, b *types.TypeName) int {
+		pa, pb := pkg.Fset.Position(a.Pos()), pkg.Fset.Position(b.Pos())
+		if c := strings.Compare(pa.Filename, pb.Filename); c != 0 {
+			return c
+		}
+		return pa.Offset - pb.Offset
+	})
+
+	names := make([]string, len(objs))
+	for i, tn := range objs {
+		names[i] = tn.Name()
+	}
+	return names
+}
+
+// structFields returns the field names of each struct type declared in
+// pkg, in declaration order. Embedded fields go by their type name.
+func structFields(pkg *packages.Package) map[string][]string {
+	fields := make(map[string][]string)
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		st, ok := tn.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		names := make([]string, st.NumFields())
+		for i := range names {
+			names[i] = st.Field(i).Name()
+		}
+		fields[name] = names
+	}
+	return fields
+}
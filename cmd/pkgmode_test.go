@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePackage writes files, keyed by name, to a new module in a
+// temporary directory and returns the directory.
+func writePackage(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/p\n\ngo 1.21\n"
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestPackageModeConfig(t *testing.T) {
+	const types = `package p
+
+type Zed struct{ b, a int }
+
+type Alpha struct{}
+`
+	const methods = `package p
+
+func (Alpha) M() {}
+
+func (z Zed) A() int { return z.a }
+
+func (z Zed) B() int { return z.b }
+`
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "group-order declaration",
+			config: "group-order: declaration\n",
+			want: `package p
+
+func (z Zed) A() int { return z.a }
+
+func (z Zed) B() int { return z.b }
+
+func (Alpha) M() {}
+`,
+		},
+		{
+			name:   "sort field-order",
+			config: "sort: field-order\n",
+			want: `package p
+
+func (Alpha) M() {}
+
+func (z Zed) B() int { return z.b }
+
+func (z Zed) A() int { return z.a }
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writePackage(t, map[string]string{
+				"types.go":        types,
+				"methods.go":      methods,
+				defaultConfigFile: tt.config,
+			})
+			path := filepath.Join(dir, "methods.go")
+			if err := executeErr(t, "--package-mode", "-w", path); err != nil {
+				t.Fatalf("--package-mode -w: %v", err)
+			}
+			if got, _ := os.ReadFile(path); string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("field-order without package mode", func(t *testing.T) {
+		dir := writePackage(t, map[string]string{
+			"types.go":        types,
+			"methods.go":      methods,
+			defaultConfigFile: "sort: field-order\n",
+		})
+		err := executeErr(t, "--check", filepath.Join(dir, "methods.go"))
+		if err == nil || !strings.Contains(err.Error(), "needs --package-mode") {
+			t.Errorf("error = %v, want one asking for --package-mode", err)
+		}
+	})
+}
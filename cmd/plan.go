@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+	"github.com/spf13/cobra"
+)
+
+var planColor bool
+
+var planCmd = &cobra.Command{
+	Use:   "plan file...",
+	Short: "Show the current and proposed method order side by side",
+	Long: `Prints a table of each file's methods in their current order next to the
+order the default settings and the config file would give them. Rows
+whose method changes are marked with "*". Nothing is written.`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"go"}, cobra.ShellCompDirectiveFilterFileExt
+	},
+	RunE: runPlan,
+}
+
+func init() {
+	planCmd.Flags().BoolVar(&planColor, "color", false, "highlight moved methods with terminal colors")
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	var err error
+	config = Config{}
+	if configFile != "" {
+		config, err = loadConfig(configFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, path := range args {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		cfg, err := configFor(path)
+		if err != nil {
+			return err
+		}
+		res, err := reorder.Process(src, path, options(cfg))
+		if err != nil {
+			return err
+		}
+
+		if len(args) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", path)
+		}
+		if len(res.Moves) == 0 {
+			fmt.Println("No methods to reorder")
+			continue
+		}
+		if err := printPlan(os.Stdout, res.Moves); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printPlan writes the table for one file. moves is in the new order.
+func printPlan(w io.Writer, moves []reorder.Move) error {
+	current := slices.Clone(moves)
+	slices.SortFunc(current, func(a, b reorder.Move) int { return a.OldIndex - b.OldIndex })
+
+	var table bytes.Buffer
+	tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tCURRENT\t#\tPROPOSED\t")
+	moved := make([]bool, len(moves))
+	for i, m := range moves {
+		was, now := qualifiedName(current[i]), qualifiedName(m)
+		mark := ""
+		if was != now {
+			mark = "*"
+			moved[i] = true
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\n", i, was, i, now, mark)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Colors are added once the columns are laid out, so the escape
+	// codes do not count towards their widths
+	lines := strings.SplitAfter(table.String(), "\n")
+	for i, line := range lines {
+		if planColor && i > 0 && i <= len(moved) && moved[i-1] {
+			line = "\x1b[33m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func qualifiedName(m reorder.Move) string {
+	if m.Receiver == "" {
+		return m.Method
+	}
+	return m.Receiver + "." + m.Method
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// presets maps each --preset name to the flag values it stands for.
+var presets = map[string]map[string]string{
+	// Plain alphabetical order within each receiver
+	"alphabetical": {
+		"sort":              "name",
+		"group-by-receiver": "true",
+	},
+	// Exported methods first, the usual interface methods ahead of
+	// them, moving as little as possible
+	"google": {
+		"sort":         "visibility",
+		"convention":   "stringer-first",
+		"minimal-diff": "true",
+	},
+	// Each type reads like its API reference: constructor, common
+	// interface methods, exported methods, deprecated ones last
+	"api": {
+		"sort":               "visibility",
+		"convention":         "stringer-first",
+		"include-functions":  "true",
+		"group-constructors": "true",
+		"deprecated-last":    "true",
+	},
+}
+
+// presetNames returns the names of the presets, sorted.
+func presetNames() []string {
+	return slices.Sorted(maps.Keys(presets))
+}
+
+// applyPreset sets the flags of the named preset that were not given on
+// the command line.
+func applyPreset(cmd *cobra.Command, name string) error {
+	values, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("invalid preset %q: must be one of %s", name, strings.Join(presetNames(), ", "))
+	}
+	for _, flag := range slices.Sorted(maps.Keys(values)) {
+		if cmd.Flags().Changed(flag) {
+			continue
+		}
+		if err := cmd.Flags().Set(flag, values[flag]); err != nil {
+			return fmt.Errorf("failed to apply preset %s: %w", name, err)
+		}
+	}
+	return nil
+}
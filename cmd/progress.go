@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+)
+
+// progress shows how many of a batch of files are done on the last line
+// of stderr. It only draws when stderr is a terminal, so redirected
+// output stays clean.
+type progress struct {
+	total, done int
+	enabled     bool
+}
+
+func newProgress(total int) *progress {
+	return &progress{
+		total:   total,
+		enabled: showProgress && !quiet && total > 1 && isTerminal(os.Stderr),
+	}
+}
+
+// clear erases the progress line so other output can be printed.
+func (p *progress) clear() {
+	if p.enabled && p.done > 0 {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+	}
+}
+
+// step counts one more file as done and redraws the line.
+func (p *progress) step() {
+	p.done++
+	if p.enabled {
+		fmt.Fprintf(os.Stderr, "\r%d/%d files", p.done, p.total)
+	}
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
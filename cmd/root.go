@@ -2,138 +2,988 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
+	"go/scanner"
+	"io"
+	"io/fs"
 	"os"
-	"sort"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"text/template"
 
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-type Method struct {
-	decl  *ast.FuncDecl
-	start token.Pos
-	end   token.Pos
+var rootCmd = &cobra.Command{
+	Use:   "reordertool [file|dir]...",
+	Short: "Reorders Go methods in a file alphabetically by name",
+	Long: `Reorders Go methods in a file alphabetically by name.
+
+Like gofmt, the reordered source is printed to stdout by default. Pass
+-w/--write to overwrite the file in place instead, or -o/--output to
+write a sorted copy to another file, or into a directory when several
+files are given. If the file argument is "-", the source is read from
+stdin and written to stdout unless --output is set.
+
+Directories are walked recursively and every .go file in them is
+processed; vendor and testdata directories, directories starting with
+"." or "_" and _test.go files are skipped (use --include-tests to keep
+test files).
+Files named explicitly are always processed. A failure in one file does
+not stop the others; all errors are reported at the end.
+
+With --check, nothing is written; the file name is printed and the exit
+status is non-zero if the methods are not already in order. --diff
+behaves the same way but prints a unified diff instead of the name;
+adding -n/--dry-run marks where each receiver group starts in the
+proposed side with a "// --- T ---" comment that is never written.
+--fail-on-change is the same check with a message for each file and a
+hint to run with -w, for CI logs.
+--report-unsorted does the same over a whole tree but prints nothing
+else, so the list of paths can be fed back to the tool.
+--since=ref checks only the .go files git reports as changed since ref.
+--watch=dir keeps running and rewrites .go files under dir in place
+each time they are saved, until interrupted.
+
+With --check, --diff and --write, files found already sorted are
+remembered in a cache (see --cache-dir) and skipped on later runs until
+they change. The cache starts over whenever the options or the tool
+version differ; --no-cache bypasses it.
+
+--convention=stringer-first sorts String, GoString, Error, Format,
+MarshalJSON, UnmarshalJSON, MarshalText, UnmarshalText, MarshalBinary,
+UnmarshalBinary, MarshalYAML and UnmarshalYAML first, in that order,
+ahead of any priority list from the config file.
+
+Exit status: 0 when no changes are needed (or they were written), 1 when
+--check, --diff, --json or --detect-duplicates found something to change,
+and 2 on operational errors such as unreadable or unparsable files.`,
+	Args:          checkArgs,
+	RunE:          run,
+	SilenceErrors: true,
 }
 
-type ByName []Method
+// errNotSorted is returned by run when --check finds a file out of order.
+var errNotSorted = errors.New("methods are not sorted")
 
-func (m ByName) Len() int           { return len(m) }
-func (m ByName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
-func (m ByName) Less(i, j int) bool { return m[i].decl.Name.Name < m[j].decl.Name.Name }
+// errDuplicates is returned by run when --detect-duplicates finds a
+// method declared twice.
+var errDuplicates = errors.New("duplicate methods found")
 
-type ByPos []Method
+// errSkipped is returned by run when files were skipped by --skip-errors.
+var errSkipped = errors.New("some files could not be parsed and were skipped")
 
-func (m ByPos) Len() int           { return len(m) }
-func (m ByPos) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
-func (m ByPos) Less(i, j int) bool { return m[i].start < m[j].start }
+var (
+	dryRun       bool
+	writeInPlace bool
+	keepPrefixes []string
+	groupByRecv  bool
+	sortMode     string
+	checkOnly    bool
+	runGofmt     bool
+	backup       bool
+	showDiff     bool
+	configFile   string
+	config       Config
+	includeTests bool
+	verbose      bool
+	skipErrors   bool
+	pairAccess   bool
+	excludeExpr  string
+	exclude      *regexp.Regexp
+	jsonOutput   bool
+	matchIface   string
+	ifaceFile    string
+	ifaceSrc     []byte
+	listOnly     bool
+	blankLines   int
+	groupBlanks  int
+	onlyRecv     string
+	detectDups   bool
+	fromFile     string
+	quiet        bool
+	reverse      bool
 
-var rootCmd = &cobra.Command{
-	Use:   "reordertool [file]",
-	Short: "Reorders Go methods in a file alphabetically by name",
-	Args:  cobra.ExactArgs(1),
-	RunE:  run,
+	respectGitignore bool
+	maxProcs         int
+	warnMixedRecv    bool
+	packageMode      bool
+	keepSections     bool
+	sinceRef         string
+	groupOrder       string
+	trimSpace        bool
+	showCount        bool
+	convention       string
+	includeFuncs     bool
+	foldCase         bool
+	withinGroup      string
+	watchDir         string
+	outputPath       string
+	outputIsDir      bool
+	formatTmpl       string
+	summaryTmpl      *template.Template
+	groupCtors       bool
+	recvOrder        []string
+	orderTests       bool
+	noNewExclusion   bool
+	minimalDiff      bool
+	reportUnsorted   bool
+	failOnChange     bool
+	deprecatedLast   bool
+	showProgress     bool
+	noComments       bool
+	sortIfaces       bool
+	preset           string
+	sarifOutput      bool
+	countOnly        bool
+	requireClean     bool
+	force            bool
+	cacheDir         string
+	noCache          bool
+	cache            *sortedCache
+	lineRange        string
+	firstLine        int
+	lastLine         int
+)
+
+type moveRecord struct {
+	File     string `json:"file"`
+	Receiver string `json:"receiver"`
+	Method   string `json:"method"`
+	OldIndex int    `json:"oldIndex"`
+	NewIndex int    `json:"newIndex"`
+}
+
+func init() {
+	rootCmd.Flags().BoolVarP(&writeInPlace, "write", "w", false, "write the result to the file instead of stdout")
+	rootCmd.Flags().BoolVar(&warnMixedRecv, "warn-mixed-receivers", false, "warn about types that mix pointer and value receivers")
+	rootCmd.Flags().BoolVar(&detectDups, "detect-duplicates", false, "report methods declared twice on the same receiver and write nothing")
+	rootCmd.Flags().BoolVar(&listOnly, "list", false, "print the methods in their new order, one per line, without writing anything")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "print the planned moves as JSON instead of the reordered source")
+	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "suppress informational messages; errors, diffs and check results are still printed")
+	rootCmd.Flags().StringVar(&formatTmpl, "format", "", "text/template for the message printed for each written file, with fields File, Output, MethodCount, MovedCount and Changed")
+	rootCmd.Flags().BoolVar(&showCount, "count", false, "print a summary of processed, changed, skipped and failed files at the end")
+	rootCmd.Flags().BoolVar(&showProgress, "progress", true, "show how many files are done on stderr when it is a terminal")
+	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "report method counts and moves for every processed file")
+	rootCmd.Flags().BoolVar(&skipErrors, "skip-errors", false, "warn about files that fail to parse and carry on (default true with several files)")
+	rootCmd.Flags().StringVar(&fromFile, "from-file", "", "read the paths to process from this file, one per line")
+	rootCmd.Flags().IntVar(&maxProcs, "max-procs", runtime.GOMAXPROCS(0), "number of files processed in parallel")
+	rootCmd.Flags().StringVar(&cacheDir, "cache-dir", "", "directory of the cache of files found already sorted, skipped on the next run (default a reordertool directory in the user cache directory)")
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "neither use nor update the cache of sorted files")
+	rootCmd.Flags().BoolVar(&respectGitignore, "respect-gitignore", true, "skip paths ignored by .gitignore when walking directories")
+	rootCmd.Flags().BoolVar(&orderTests, "order-tests", false, "in foo_test.go, order TestX functions like the X they test are declared in foo.go")
+	rootCmd.Flags().BoolVar(&includeTests, "include-tests", false, "also process _test.go files found when walking directories")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file to use for every file (default the "+defaultConfigFile+" files from each file's directory up to the repository root, nearer ones winning)")
+	rootCmd.Flags().BoolVar(&requireClean, "require-clean", false, "when writing in place, skip with a warning files that have uncommitted git changes")
+	rootCmd.Flags().BoolVar(&force, "force", false, "with --require-clean, reorder files with uncommitted changes anyway")
+	rootCmd.Flags().BoolVar(&backup, "backup", false, "save the original file as <file>.bak before overwriting it")
+	rootCmd.Flags().StringVarP(&outputPath, "output", "o", "", "write the result to this file, or into this directory, leaving the input untouched")
+	rootCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "print the reordered source to stdout even when --write is set")
+	rootCmd.Flags().BoolVar(&checkOnly, "check", false, "report whether the file is sorted without writing anything")
+	rootCmd.Flags().BoolVar(&failOnChange, "fail-on-change", false, "like --check, but name each file that would change and suggest running with -w")
+	rootCmd.Flags().BoolVar(&countOnly, "count-only", false, "print only the number of files that are not sorted; implies --check")
+	rootCmd.Flags().BoolVar(&sarifOutput, "sarif", false, "print the unsorted files as a SARIF report for code scanning; implies --check")
+	rootCmd.Flags().BoolVar(&reportUnsorted, "report-unsorted", false, "print only the paths of files that are not sorted, one per line; implies --check and --quiet")
+	rootCmd.Flags().StringVar(&watchDir, "watch", "", "keep running and reorder .go files under this directory in place whenever they change")
+	rootCmd.Flags().StringVar(&sinceRef, "since", "", "check the .go files changed since this git ref; implies --check")
+	rootCmd.Flags().BoolVarP(&showDiff, "diff", "d", false, "print a unified diff of the changes instead of the reordered source")
+	rootCmd.Flags().IntVar(&blankLines, "separator", 1, "blank lines between reordered methods (gofmt collapses more than 1)")
+	rootCmd.Flags().IntVar(&groupBlanks, "group-separator", 1, "blank lines between receiver groups, if larger than --separator; kept through gofmt")
+	rootCmd.Flags().IntVar(&groupBlanks, "group-blank-lines", 1, "same as --group-separator")
+	rootCmd.Flags().BoolVar(&keepSections, "preserve-sections", false, "sort runs of methods separated by two or more blank lines independently, keeping the separators")
+	rootCmd.Flags().BoolVar(&minimalDiff, "minimal-diff", false, "move as few methods as possible, leaving the longest already ordered run in place")
+	rootCmd.Flags().BoolVar(&trimSpace, "trim-trailing-whitespace", false, "strip trailing spaces and tabs from every line of the result")
+	rootCmd.Flags().BoolVar(&sortIfaces, "sort-interfaces", false, "also sort the methods of interface types by name, after embedded interfaces")
+	rootCmd.Flags().BoolVar(&noComments, "no-comments", false, "parse without comments for speed; doc comments then stay behind when their method moves")
+	rootCmd.Flags().BoolVar(&runGofmt, "gofmt", true, "format the reordered source with gofmt")
+	rootCmd.Flags().StringVar(&preset, "preset", "", "set several flags at once: "+strings.Join(presetNames(), ", ")+"; flags given explicitly still win")
+	rootCmd.Flags().StringVar(&sortMode, "sort", reorder.SortByName, "sort mode: "+strings.Join(reorder.SortModes, ", ")+" (caller and field-order are experimental; field-order needs --package-mode)")
+	rootCmd.Flags().StringVar(&convention, "convention", "", "put the methods of a built-in convention first: "+reorder.ConventionStringerFirst)
+	rootCmd.Flags().BoolVar(&deprecatedLast, "deprecated-last", false, "move methods whose doc starts a paragraph with \"Deprecated:\" to the end of their group")
+	rootCmd.Flags().BoolVar(&reverse, "reverse", false, "reverse the order of the selected sort mode")
+	rootCmd.Flags().BoolVar(&foldCase, "fold-case", false, "with --sort=name, compare names case-insensitively")
+	rootCmd.Flags().BoolVar(&pairAccess, "pair-accessors", false, "with --sort=name, keep GetX and SetX next to each other")
+	rootCmd.Flags().BoolVar(&groupByRecv, "group-by-receiver", true, "keep methods of the same receiver type together")
+	rootCmd.Flags().StringSliceVar(&recvOrder, "receiver-order", nil, "receiver types whose groups come first, in this order; others follow alphabetically")
+	rootCmd.Flags().StringVar(&withinGroup, "within-group", "", "sort mode inside each receiver group (default the --sort mode)")
+	rootCmd.Flags().StringVar(&groupOrder, "group-order", reorder.GroupOrderName, "receiver group order: "+strings.Join(reorder.GroupOrders, ", ")+" (declaration uses the whole package with --package-mode)")
+	rootCmd.Flags().BoolVar(&packageMode, "package-mode", false, "load the enclosing package for type information (slower, needs a buildable package)")
+	rootCmd.Flags().StringVar(&matchIface, "match-interface", "", "order methods of types implementing this interface like the interface declares them")
+	rootCmd.Flags().StringVar(&ifaceFile, "interface-file", "", "file declaring the --match-interface interface (default the processed file)")
+	rootCmd.Flags().BoolVar(&includeFuncs, "include-functions", false, "also sort plain functions, as one block ahead of the methods")
+	rootCmd.Flags().BoolVar(&groupCtors, "group-constructors", false, "with --include-functions, put each NewT constructor right before the methods of T")
+	rootCmd.Flags().StringVar(&lineRange, "range", "", "only reorder methods lying entirely within these 1-based lines, as start:end")
+	rootCmd.Flags().StringVar(&onlyRecv, "only-receiver", "", "only reorder methods of this receiver type")
+	rootCmd.Flags().StringVar(&excludeExpr, "exclude", "", "regular expression of method names to leave in place, in addition to --keep-prefix")
+	rootCmd.Flags().StringSliceVar(&keepPrefixes, "keep-prefix", []string{"New"}, "method name prefixes to leave out of the reordering (--keep-prefix= for none)")
+	rootCmd.Flags().BoolVar(&noNewExclusion, "no-new-exclusion", false, "let New-prefixed methods take part in the reordering")
+
+	rootCmd.Version = version()
+	registerCompletions()
 }
 
+// ExitCode is the process exit status of the command.
+type ExitCode int
+
+const (
+	// ExitOK means no changes were needed, or they were written.
+	ExitOK ExitCode = 0
+	// ExitChanges means a check found files that need changes.
+	ExitChanges ExitCode = 1
+	// ExitError means an operational failure such as an IO or parse error.
+	ExitError ExitCode = 2
+)
+
 func Execute() {
 	err := rootCmd.Execute()
-	if err != nil {
-		os.Exit(1)
+	code := exitCode(err)
+	if code == ExitError {
+		// Several files can fail, and a parse error lists every problem
+		// found, so give each its own line
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "Error:", line)
+		}
+	}
+	os.Exit(int(code))
+}
+
+// exitCode maps the error returned by the command to its exit status.
+func exitCode(err error) ExitCode {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, errNotSorted), errors.Is(err, errDuplicates):
+		return ExitChanges
+	default:
+		return ExitError
+	}
+}
+
+// checkArgs requires at least one path unless --from-file or --since
+// supplies them. --watch takes its directory as the only path.
+func checkArgs(cmd *cobra.Command, args []string) error {
+	if watchDir != "" {
+		if len(args) > 0 || fromFile != "" || sinceRef != "" {
+			return errors.New("--watch cannot be combined with other paths, --from-file or --since")
+		}
+		return nil
+	}
+	if fromFile != "" || sinceRef != "" {
+		return nil
 	}
+	return cobra.MinimumNArgs(1)(cmd, args)
 }
 
 func run(cmd *cobra.Command, args []string) error {
-	inputFile := args[0]
+	if preset != "" {
+		if err := applyPreset(cmd, preset); err != nil {
+			return err
+		}
+	}
+	if !reorder.ValidSortMode(sortMode) {
+		return fmt.Errorf("invalid sort mode %q: must be one of %s, or %sNAME for a registered comparator", sortMode, strings.Join(reorder.SortModes, ", "), reorder.CustomPrefix)
+	}
+	if withinGroup != "" && !reorder.ValidSortMode(withinGroup) {
+		return fmt.Errorf("invalid --within-group sort mode %q: must be one of %s, or %sNAME for a registered comparator", withinGroup, strings.Join(reorder.SortModes, ", "), reorder.CustomPrefix)
+	}
+	if (sortMode == reorder.SortByFieldOrder || withinGroup == reorder.SortByFieldOrder) && !packageMode {
+		return fmt.Errorf("--sort=%s needs --package-mode", reorder.SortByFieldOrder)
+	}
+	if !slices.Contains(reorder.GroupOrders, groupOrder) {
+		return fmt.Errorf("invalid group order %q: must be one of %s", groupOrder, strings.Join(reorder.GroupOrders, ", "))
+	}
+	if _, ok := reorder.Conventions[convention]; convention != "" && !ok {
+		return fmt.Errorf("invalid convention %q: must be %s", convention, reorder.ConventionStringerFirst)
+	}
+	if countOnly && (showDiff || listOnly || jsonOutput || writeInPlace || reportUnsorted || failOnChange || sarifOutput) {
+		return errors.New("--count-only cannot be combined with --diff, --list, --json, --write, --report-unsorted, --fail-on-change or --sarif")
+	}
+	if sarifOutput && (showDiff || listOnly || jsonOutput || writeInPlace || reportUnsorted || failOnChange) {
+		return errors.New("--sarif cannot be combined with --diff, --list, --json, --write, --report-unsorted or --fail-on-change")
+	}
+	if failOnChange && (reportUnsorted || writeInPlace) {
+		return errors.New("--fail-on-change cannot be combined with --report-unsorted or --write")
+	}
+	if reportUnsorted && (showDiff || listOnly || jsonOutput || writeInPlace) {
+		return errors.New("--report-unsorted cannot be combined with --diff, --list, --json or --write")
+	}
+	firstLine, lastLine = 0, 0
+	if lineRange != "" {
+		var err error
+		firstLine, lastLine, err = parseRange(lineRange)
+		if err != nil {
+			return err
+		}
+	}
+	summaryTmpl = nil
+	if formatTmpl != "" {
+		text := formatTmpl
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		t, err := template.New("format").Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid --format template: %w", err)
+		}
+		// Fail now rather than after the first file is written
+		if err := t.Execute(io.Discard, fileSummary{}); err != nil {
+			return fmt.Errorf("invalid --format template: %w", err)
+		}
+		summaryTmpl = t
+	}
+	exclude = nil
+	if excludeExpr != "" {
+		re, err := regexp.Compile(excludeExpr)
+		if err != nil {
+			return fmt.Errorf("invalid --exclude expression: %w", err)
+		}
+		exclude = re
+	}
+	cmd.SilenceUsage = true
+
+	explicitFlags = make(map[string]bool)
+	// Visit also sees flags set by an earlier Execute, as in tests
+	cmd.Flags().VisitAll(func(f *pflag.Flag) { explicitFlags[f.Name] = f.Changed })
+
+	var err error
+	config = Config{}
+	if configFile != "" {
+		config, err = loadConfig(configFile)
+		if err != nil {
+			return err
+		}
+	}
 
-	src, err := os.ReadFile(inputFile)
+	ifaceSrc = nil
+	if ifaceFile != "" {
+		ifaceSrc, err = os.ReadFile(ifaceFile)
+		if err != nil {
+			return fmt.Errorf("failed to read interface file %s: %w", ifaceFile, err)
+		}
+	}
+
+	if watchDir != "" {
+		writeInPlace = true
+		if cache, err = openCache(); err != nil {
+			return err
+		}
+		defer func() {
+			if err := cache.save(); err != nil {
+				fmt.Fprintln(os.Stderr, "Warning:", err)
+			}
+		}()
+		return watch(watchDir)
+	}
+
+	if fromFile != "" {
+		listed, err := readFileList(fromFile)
+		if err != nil {
+			return err
+		}
+		args = append(args, listed...)
+	}
+
+	if sinceRef != "" {
+		changed, err := changedFiles(sinceRef)
+		if err != nil {
+			return err
+		}
+		args = append(args, changed...)
+		checkOnly = true
+	}
+
+	if reportUnsorted {
+		checkOnly, quiet = true, true
+	}
+	if failOnChange || sarifOutput || countOnly {
+		checkOnly = true
+	}
+
+	cache, err = openCache()
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", inputFile, err)
+		return err
+	}
+
+	files, errs := collectFiles(args)
+	multi := len(files) > 1
+
+	if lineRange != "" && multi {
+		return errors.New("--range applies to a single file")
+	}
+
+	if err := checkOutput(files, multi); err != nil {
+		return err
 	}
 
-	fSet := token.NewFileSet()
-	file, err := parser.ParseFile(fSet, inputFile, src, parser.ParseComments)
+	if !cmd.Flags().Changed("skip-errors") {
+		skipErrors = multi
+	}
+
+	var jsonMoves []moveRecord
+	var findings []sarifResult
+	unsorted := 0
+	notSorted, skipped, duplicates := false, false, false
+	var flushErr error
+	var sum summary
+	bar := newProgress(len(files))
+	processAll(files, multi, func(path string, w *fileOutput) bool {
+		sum.processed++
+		if w.changed {
+			sum.changed++
+		}
+		bar.clear()
+		defer bar.step()
+		if _, err := os.Stdout.Write(w.stdout.Bytes()); err != nil && flushErr == nil {
+			flushErr = fmt.Errorf("failed to write to stdout: %w", err)
+		}
+		os.Stderr.Write(w.stderr.Bytes())
+		jsonMoves = append(jsonMoves, w.moves...)
+		if w.finding != nil {
+			findings = append(findings, *w.finding)
+		}
+
+		err := w.err
+		var list scanner.ErrorList
+		switch {
+		case errors.Is(err, errNotSorted):
+			notSorted = true
+			unsorted++
+		case errors.Is(err, errDuplicates):
+			duplicates = true
+		case errors.As(err, &list):
+			if !skipErrors {
+				errs = append(errs, parseErrors(list))
+				return false
+			}
+			for _, e := range list {
+				fmt.Fprintln(os.Stderr, "Warning:", e)
+			}
+			skipped = true
+			sum.skipped++
+		case err != nil:
+			errs = append(errs, err)
+		}
+		return true
+	})
+	bar.clear()
+	if flushErr != nil {
+		errs = append(errs, flushErr)
+	}
+	if err := cache.save(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if showCount {
+		sum.errors = len(errs)
+		infof(os.Stderr, "%s\n", sum)
+	}
+
+	if jsonOutput {
+		if err := printJSON(jsonMoves); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if sarifOutput {
+		if err := printSARIF(findings); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if countOnly {
+		fmt.Println(unsorted)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if skipped {
+		return errSkipped
+	}
+	if duplicates {
+		return errDuplicates
+	}
+	if notSorted {
+		if failOnChange {
+			infof(os.Stderr, "Run with -w to reorder the methods in place\n")
+		}
+		return errNotSorted
+	}
+	return nil
+}
+
+// parseErrors expands a parser error list into one error per problem,
+// each starting with its file:line:column position.
+func parseErrors(list scanner.ErrorList) error {
+	errs := make([]error, len(list))
+	for i, e := range list {
+		errs[i] = e
+	}
+	return errors.Join(errs...)
+}
+
+// summary counts the outcomes of a batch for --count.
+type summary struct {
+	processed, changed, skipped, errors int
+}
+
+func (s summary) String() string {
+	return fmt.Sprintf("Processed %s, %d changed, %d skipped, %s",
+		plural(s.processed, "file"), s.changed, s.skipped, plural(s.errors, "error"))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// processFile reorders one file. Everything it prints goes to w, so that
+// files processed concurrently can be reported one after the other.
+func processFile(inputFile string, multi bool, w *fileOutput) error {
+	useStdin := inputFile == "-"
+
+	if useStdin {
+		inputFile = "<stdin>.go"
+	}
+	cfg, err := configFor(inputFile)
 	if err != nil {
-		return fmt.Errorf("failed to parse file %s: %w", inputFile, err)
+		return err
+	}
+	opts := options(cfg)
+	// A config file can ask for field order without the flag
+	if (opts.SortMode == reorder.SortByFieldOrder || opts.WithinGroup == reorder.SortByFieldOrder) && (!packageMode || useStdin) {
+		return fmt.Errorf("sort mode %s for %s needs --package-mode", reorder.SortByFieldOrder, inputFile)
 	}
 
-	// Separate methods vs others
-	var methods []Method
+	var src []byte
+	if useStdin {
+		src, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+	} else {
+		if cache.sorted(inputFile, opts) {
+			return nil
+		}
+		// Only an in-place write could clobber uncommitted work
+		inPlace := !toStdout(false) && outputPath == "" && !checkOnly && !showDiff && !listOnly
+		if requireClean && !force && inPlace && cleanliness.dirty(inputFile) {
+			fmt.Fprintf(&w.stderr, "Warning: %s has uncommitted changes, skipping it (use --force to reorder it anyway)\n", inputFile)
+			return nil
+		}
+		src, err = os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", inputFile, err)
+		}
+	}
+
+	if packageMode && !useStdin {
+		opts.Package, err = loader.info(inputFile, opts)
+		if err != nil {
+			return err
+		}
+	}
 
-	for _, decl := range file.Decls {
-		funcDecl, ok := decl.(*ast.FuncDecl)
-		if !ok {
-			continue
+	if orderTests && !useStdin && strings.HasSuffix(inputFile, "_test.go") {
+		opts.TestOrder, err = testOrder(inputFile)
+		if err != nil {
+			return err
 		}
-		if funcDecl.Recv == nil {
-			continue
+		// Test functions have no receiver
+		if opts.TestOrder != nil {
+			opts.IncludeFunctions = true
 		}
+	}
 
-		// Exclude constructors or funcs starting with "New"
-		if strings.HasPrefix(funcDecl.Name.Name, "New") && funcDecl.Recv.NumFields() > 0 {
-			continue
+	res, err := reorder.Process(src, inputFile, opts)
+	if err != nil {
+		return err
+	}
+	out := res.Output
+	w.changed = !bytes.Equal(src, out)
+	w.methods, w.moved = res.Methods, res.Moved
+	// Files without methods are not cached, so their note still shows
+	cacheable := !useStdin && res.Methods > 0 && len(res.Warnings) == 0
+	if cacheable && !w.changed {
+		cache.add(inputFile, opts)
+	}
+
+	for _, warning := range res.Warnings {
+		fmt.Fprintln(&w.stderr, "Warning:", warning)
+	}
+
+	if warnMixedRecv {
+		for _, m := range res.MixedReceivers {
+			fmt.Fprintf(&w.stderr, "Warning: %s: type %s mixes pointer receivers (%s) and value receivers (%s)\n",
+				inputFile, m.Type, strings.Join(m.Pointer, ", "), strings.Join(m.Value, ", "))
 		}
+	}
 
-		start := funcDecl.Pos()
-		if funcDecl.Doc != nil {
-			start = funcDecl.Doc.Pos()
+	if detectDups {
+		for _, d := range res.Duplicates {
+			fmt.Fprintf(&w.stdout, "%s:%d: method %s.%s already declared at line %d\n",
+				inputFile, d.Line, d.Receiver, d.Method, d.FirstLine)
+		}
+		if len(res.Duplicates) > 0 {
+			return errDuplicates
 		}
-		end := funcDecl.End()
+		return nil
+	}
 
-		methods = append(methods, Method{decl: funcDecl, start: start, end: end})
+	// Methods elsewhere, such as in interfaces, may still have moved
+	if res.Methods == 0 && !w.changed {
+		if multi {
+			infof(&w.stderr, "No methods to reorder in %s\n", inputFile)
+		} else {
+			infof(&w.stderr, "No methods to reorder\n")
+		}
+		if checkOnly || showDiff || jsonOutput || listOnly {
+			return nil
+		}
+		if toStdout(useStdin) || outputPath != "" {
+			return writeOutput(w, inputFile, useStdin, multi, src, src)
+		}
+		return nil
 	}
 
-	if len(methods) == 0 {
-		fmt.Printf("No methods to reorder\n")
+	if listOnly {
+		for _, m := range res.Moves {
+			if multi {
+				fmt.Fprintf(&w.stdout, "%s: ", inputFile)
+			}
+			fmt.Fprintln(&w.stdout, qualifiedName(m))
+		}
 		return nil
 	}
 
-	// Sort methods alphabetically by name
-	sort.Sort(ByName(methods))
+	if showDiff {
+		proposed := out
+		if dryRun && grouping(opts) {
+			proposed = withGroupHeaders(out)
+		}
+		d := unifiedDiff(inputFile, src, proposed)
+		if d == "" {
+			return nil
+		}
+		w.stdout.WriteString(d)
+		return errNotSorted
+	}
+
+	if checkOnly {
+		if bytes.Equal(src, out) {
+			return nil
+		}
+		switch {
+		case countOnly:
+			// Only counted, by run
+		case sarifOutput:
+			finding := sarifFinding(inputFile, res.Moves)
+			w.finding = &finding
+		case failOnChange:
+			fmt.Fprintf(&w.stdout, "%s: methods would be reordered\n", inputFile)
+		default:
+			fmt.Fprintln(&w.stdout, inputFile)
+		}
+		return errNotSorted
+	}
+
+	changed := !bytes.Equal(src, out)
+	if jsonOutput {
+		for _, m := range res.Moves {
+			w.moves = append(w.moves, moveRecord{
+				File:     inputFile,
+				Receiver: m.Receiver,
+				Method:   m.Method,
+				OldIndex: m.OldIndex,
+				NewIndex: m.NewIndex,
+			})
+		}
+		if !changed {
+			return nil
+		}
+		// With -w the file is still written, but the source is not printed
+		if toStdout(useStdin) {
+			return errNotSorted
+		}
+		if err := writeOutput(w, inputFile, useStdin, multi, src, out); err != nil {
+			return err
+		}
+		return errNotSorted
+	}
+
+	// A copy made with --output is written even if nothing moved
+	written := !toStdout(useStdin) && (changed || outputPath != "")
+	if verbose {
+		infof(&w.stderr, "%s: %d methods, %d moved, written: %t\n",
+			inputFile, res.Methods, res.Moved, written)
+	}
+
+	// Leave files that are already in order untouched
+	if !toStdout(useStdin) && !written {
+		return nil
+	}
+
+	if err := writeOutput(w, inputFile, useStdin, multi, src, out); err != nil {
+		return err
+	}
+	if cacheable {
+		cache.add(inputFile, opts)
+	}
+	return nil
+}
+
+// testOrder returns the symbol order of the file tested by testFile,
+// foo.go for foo_test.go, or nil if there is no such file.
+func testOrder(testFile string) ([]string, error) {
+	target := strings.TrimSuffix(testFile, "_test.go") + ".go"
+	src, err := os.ReadFile(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", target, err)
+	}
+	return reorder.SymbolOrder(src, target)
+}
+
+// options builds the reorder options from the command line flags and
+// cfg, the config of the file processed.
+func options(cfg Config) reorder.Options {
+	opts := reorder.Options{
+		SortMode:          sortMode,
+		KeepPrefixes:      withoutNew(keepPrefixes),
+		OnlyReceiver:      onlyRecv,
+		FirstLine:         firstLine,
+		LastLine:          lastLine,
+		Exclude:           exclude,
+		GroupByReceiver:   groupByRecv,
+		WithinGroup:       withinGroup,
+		GroupOrder:        groupOrder,
+		ReceiverOrder:     recvOrder,
+		Reverse:           reverse,
+		PairAccessors:     pairAccess,
+		FoldCase:          foldCase,
+		Priority:          append(slices.Clip(reorder.Conventions[convention]), cfg.Priority...),
+		DeprecatedLast:    deprecatedLast,
+		MatchInterface:    matchIface,
+		InterfaceSrc:      ifaceSrc,
+		InterfaceFile:     ifaceFile,
+		BlankLines:        blankLines,
+		GroupBlankLines:   groupBlanks,
+		PreserveSections:  keepSections,
+		MinimalDiff:       minimalDiff,
+		TrimTrailingSpace: trimSpace,
+		NoComments:        noComments,
+		SortInterfaces:    sortIfaces,
+		IncludeFunctions:  includeFuncs,
+		GroupConstructors: groupCtors,
+		Format:            runGofmt,
+	}
+	cfg.apply(&opts)
+	return opts
+}
+
+// parseRange parses the start:end value of --range.
+func parseRange(s string) (first, last int, err error) {
+	start, end, ok := strings.Cut(s, ":")
+	if ok {
+		first, err = strconv.Atoi(start)
+	}
+	if ok && err == nil {
+		last, err = strconv.Atoi(end)
+	}
+	if !ok || err != nil || first < 1 || last < first {
+		return 0, 0, fmt.Errorf("invalid --range %q: must be start:end with 1 <= start <= end", s)
+	}
+	return first, last, nil
+}
+
+// withoutNew returns list without "New" if --no-new-exclusion is set.
+func withoutNew(list []string) []string {
+	if !noNewExclusion {
+		return list
+	}
+	var prefixes []string
+	for _, p := range list {
+		if p != "New" {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
 
-	// To get the block, sort by position to find first and last
-	posMethods := append([]Method(nil), methods...)
-	sort.Sort(ByPos(posMethods))
-	firstStartOff := fSet.Position(posMethods[0].start).Offset
-	lastEndOff := fSet.Position(posMethods[len(posMethods)-1].end).Offset
+func printJSON(jsonMoves []moveRecord) error {
+	if jsonMoves == nil {
+		jsonMoves = []moveRecord{}
+	}
+	data, err := json.MarshalIndent(jsonMoves, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
 
-	// Get sorted sources
-	var sortedSources []string
-	for _, m := range methods {
-		startOff := fSet.Position(m.start).Offset
-		endOff := fSet.Position(m.end).Offset
-		sortedSources = append(sortedSources, string(src[startOff:endOff]))
+// infof prints an informational message unless --quiet is set.
+func infof(w io.Writer, format string, args ...any) {
+	if quiet {
+		return
 	}
+	fmt.Fprintf(w, format, args...)
+}
 
-	joined := strings.Join(sortedSources, "\n\n")
+// toStdout reports whether output should go to stdout rather than the
+// input file or --output.
+func toStdout(useStdin bool) bool {
+	if dryRun {
+		return true
+	}
+	if outputPath != "" {
+		return false
+	}
+	return useStdin || !writeInPlace
+}
 
-	// Build new source
-	var newSrc bytes.Buffer
-	newSrc.Write(src[0:firstStartOff])
-	newSrc.WriteString(joined)
-	newSrc.Write(src[lastEndOff:])
+// checkOutput validates --output against the files to process and
+// records whether it names a directory.
+func checkOutput(files []string, multi bool) error {
+	outputIsDir = false
+	if outputPath == "" {
+		return nil
+	}
+	if info, err := os.Stat(outputPath); err == nil {
+		outputIsDir = info.IsDir()
+	}
+	if multi && !outputIsDir {
+		return fmt.Errorf("--output must be an existing directory when processing several files")
+	}
 
-	// Write output.txt
-	//outputFile := "output.txt"
-	//if err := os.WriteFile(outputFile, newSrc.Bytes(), 0644); err != nil {
-	//	return fmt.Errorf("failed to write output file %s: %w", outputFile, err)
-	//}
+	seen := make(map[string]string)
+	for _, f := range files {
+		if f == "-" && outputIsDir {
+			return fmt.Errorf("--output must be a file when reading stdin")
+		}
+		target := outputTarget(f)
+		if prev, ok := seen[target]; ok {
+			return fmt.Errorf("--output: %s and %s would both be written to %s", prev, f, target)
+		}
+		seen[target] = f
+		if !writeInPlace && sameFile(f, target) {
+			return fmt.Errorf("--output %s is the input file itself; pass -w to overwrite it", target)
+		}
+	}
+	return nil
+}
 
-	//fmt.Printf("Methods reordered and written to %s\n", outputFile)
+// outputTarget returns where --output puts the result for inputFile.
+func outputTarget(inputFile string) string {
+	if outputIsDir {
+		return filepath.Join(outputPath, filepath.Base(inputFile))
+	}
+	return outputPath
+}
 
-	// Open output.txt in TextEdit (macOS)
-	//err = exec.Command("open", "-a", "TextEdit", outputFile).Start()
-	//if err != nil {
-	//	return fmt.Errorf("failed to open %s in TextEdit: %w", outputFile, err)
-	//}
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	return err == nil && os.SameFile(ia, ib)
+}
 
-	if err := os.WriteFile(inputFile, newSrc.Bytes(), 0644); err != nil {
+// writeOutput writes out to stdout or back to inputFile. When several
+// files go to stdout, each is preceded by a comment naming it.
+func writeOutput(w *fileOutput, inputFile string, useStdin, multi bool, src, out []byte) error {
+	if toStdout(useStdin) {
+		if multi {
+			fmt.Fprintf(&w.stdout, "// %s\n", inputFile)
+		}
+		w.stdout.Write(out)
+		return nil
+	}
+
+	if outputPath != "" {
+		return writeCopy(w, inputFile, useStdin, out)
+	}
+
+	info, err := os.Stat(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", inputFile, err)
+	}
+	perm := info.Mode().Perm()
+
+	if backup {
+		backupFile := inputFile + ".bak"
+		if err := os.WriteFile(backupFile, src, perm); err != nil {
+			return fmt.Errorf("failed to write backup file %s: %w", backupFile, err)
+		}
+	}
+
+	if err := writeFileAtomic(inputFile, out, perm); err != nil {
 		return fmt.Errorf("failed to write to file %s: %w", inputFile, err)
 	}
 
-	fmt.Printf("Methods reordered in %s\n", inputFile)
+	return writeSummary(w, inputFile, inputFile, "Methods reordered in {{.File}}\n")
+}
+
+// writeCopy writes out to the --output target of inputFile, with the
+// input's permissions.
+func writeCopy(w *fileOutput, inputFile string, useStdin bool, out []byte) error {
+	perm := fs.FileMode(0644)
+	if !useStdin {
+		info, err := os.Stat(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", inputFile, err)
+		}
+		perm = info.Mode().Perm()
+	}
+
+	target := outputTarget(inputFile)
+	if err := writeFileAtomic(target, out, perm); err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", target, err)
+	}
+
+	return writeSummary(w, inputFile, target, "Methods of {{.File}} written to {{.Output}}\n")
+}
+
+// fileSummary is the data available to the --format template.
+type fileSummary struct {
+	File        string
+	Output      string
+	MethodCount int
+	MovedCount  int
+	Changed     bool
+}
 
+// writeSummary prints the message for inputFile written to target, using
+// the --format template or else def.
+func writeSummary(w *fileOutput, inputFile, target, def string) error {
+	if verbose || jsonOutput || quiet {
+		return nil
+	}
+	t := summaryTmpl
+	if t == nil {
+		t = template.Must(template.New("default").Parse(def))
+	}
+
+	data := fileSummary{
+		File:        inputFile,
+		Output:      target,
+		MethodCount: w.methods,
+		MovedCount:  w.moved,
+		Changed:     w.changed,
+	}
+	if err := t.Execute(&w.stdout, data); err != nil {
+		return fmt.Errorf("failed to execute --format template for %s: %w", inputFile, err)
+	}
 	return nil
 }
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	sortedSrc = `package p
+
+type T struct{}
+
+func (T) A() {}
+
+func (T) B() {}
+`
+	unsortedSrc = `package p
+
+type T struct{}
+
+func (T) B() {}
+
+func (T) A() {}
+`
+)
+
+// execute runs the root command with args after putting every flag back
+// to its default, and returns the exit code it would exit with.
+func execute(t *testing.T, args ...string) ExitCode {
+	t.Helper()
+	return exitCode(executeErr(t, args...))
+}
+
+// executeErr is execute returning the command's error.
+func executeErr(t *testing.T, args ...string) error {
+	t.Helper()
+	rootCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if value, ok := f.Value.(pflag.SliceValue); ok {
+			var def []string
+			if s := strings.Trim(f.DefValue, "[]"); s != "" {
+				def = strings.Split(s, ",")
+			}
+			value.Replace(def)
+		} else if err := f.Value.Set(f.DefValue); err != nil {
+			t.Fatalf("failed to reset --%s: %v", f.Name, err)
+		}
+		f.Changed = false
+	})
+	rootCmd.SetArgs(append([]string{"--no-cache"}, args...))
+	return rootCmd.Execute()
+}
+
+// writeFile writes src to a file named name in a new temporary directory
+// and returns its path.
+func writeFile(t *testing.T, name, src string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(src), perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		args []string
+		want ExitCode
+	}{
+		{"check sorted", sortedSrc, []string{"--check"}, ExitOK},
+		{"check unsorted", unsortedSrc, []string{"--check"}, ExitChanges},
+		{"diff sorted", sortedSrc, []string{"--diff"}, ExitOK},
+		{"diff unsorted", unsortedSrc, []string{"--diff"}, ExitChanges},
+		{"write unsorted", unsortedSrc, []string{"-w"}, ExitOK},
+		{"parse error", "package p\n\nfunc (", []string{"--check"}, ExitError},
+		{"bad flag value", sortedSrc, []string{"--sort=nope"}, ExitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "x.go", tt.src, 0644)
+			if got := execute(t, append(tt.args, path)...); got != tt.want {
+				t.Errorf("exit code = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing.go")
+		if got := execute(t, "--check", path); got != ExitError {
+			t.Errorf("exit code = %d, want %d", got, ExitError)
+		}
+	})
+}
+
+func TestWriteKeepsFileMode(t *testing.T) {
+	for _, perm := range []os.FileMode{0600, 0755} {
+		path := writeFile(t, "x.go", unsortedSrc, perm)
+		if err := os.Chmod(path, perm); err != nil {
+			t.Fatal(err)
+		}
+		if code := execute(t, "-w", path); code != ExitOK {
+			t.Fatalf("exit code = %d, want %d", code, ExitOK)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != perm {
+			t.Errorf("mode after -w = %v, want %v", got, perm)
+		}
+		if data, _ := os.ReadFile(path); string(data) != sortedSrc {
+			t.Errorf("file not sorted:\n%s", data)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+)
+
+// sarifRuleID identifies the one finding --sarif reports.
+const sarifRuleID = "methods-not-sorted"
+
+// The subset of SARIF 2.1.0 that --sarif writes.
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name    string      `json:"name"`
+		Version string      `json:"version"`
+		Rules   []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           sarifRegion   `json:"region"`
+	}
+	sarifArtifact struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine int `json:"startLine"`
+	}
+)
+
+// sarifFinding reports the unsorted file path at its first method that
+// is not in its place, or at its first line if only formatting differs.
+func sarifFinding(path string, moves []reorder.Move) sarifResult {
+	line, text := 1, "Methods are not sorted"
+	var first *reorder.Move
+	for i, m := range moves {
+		if m.OldIndex != m.NewIndex && (first == nil || m.OldIndex < first.OldIndex) {
+			first = &moves[i]
+		}
+	}
+	if first != nil {
+		line = first.Line
+		text = fmt.Sprintf("Methods are not sorted: %s belongs in position %d, not %d", qualifiedName(*first), first.NewIndex+1, first.OldIndex+1)
+	}
+
+	return sarifResult{
+		RuleID:  sarifRuleID,
+		Level:   "warning",
+		Message: sarifMessage{Text: text},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(path)},
+				Region:           sarifRegion{StartLine: line},
+			},
+		}},
+	}
+}
+
+// printSARIF prints the --sarif report of results.
+func printSARIF(results []sarifResult) error {
+	if results == nil {
+		results = []sarifResult{}
+	}
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:    "reordertool",
+				Version: version(),
+				Rules: []sarifRule{{
+					ID:               sarifRuleID,
+					ShortDescription: sarifMessage{Text: "Methods are not in the configured order"},
+				}},
+			}},
+			Results: results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SARIF: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import "runtime/debug"
+
+// version describes the running binary from its embedded build info: the
+// module version, or "devel" for a source build, followed by the VCS
+// revision when known.
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+
+	v := info.Main.Version
+	if v == "" || v == "(devel)" {
+		v = "devel"
+	}
+
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				modified = ", modified"
+			}
+		}
+	}
+	if revision == "" {
+		return v
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	return v + " (" + revision + modified + ")"
+}
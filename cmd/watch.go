@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounceDelay is how long a file must stay quiet after a change before
+// --watch reprocesses it. Editors often save in several steps.
+const debounceDelay = 200 * time.Millisecond
+
+// watch reorders the .go files under dir in place whenever they change,
+// until interrupted.
+func watch(dir string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to start watching: %w", err)
+	}
+	defer watcher.Close()
+
+	var ignore *gitignore
+	if respectGitignore {
+		ignore = newGitignore()
+	}
+	if err := watchTree(watcher, dir, ignore); err != nil {
+		return err
+	}
+	infof(os.Stderr, "Watching %s for changes (Ctrl-C to stop)\n", dir)
+
+	// Timers fire on their own goroutines; files are processed one at a
+	// time on this one
+	ready := make(chan string)
+	timers := make(map[string]*time.Timer)
+	// What each file looked like after we last wrote it, so the events
+	// caused by our own writes are not processed again
+	written := make(map[string]fs.FileInfo)
+
+	for {
+		select {
+		case <-ctx.Done():
+			infof(os.Stderr, "Stopped watching %s\n", dir)
+			return nil
+
+		case err := <-watcher.Errors:
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+
+		case ev := <-watcher.Events:
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if err := watchTree(watcher, ev.Name, ignore); err != nil {
+						fmt.Fprintln(os.Stderr, "Warning:", err)
+					}
+					continue
+				}
+			}
+			if !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) || !watchedFile(ev.Name, ignore) {
+				continue
+			}
+			path := ev.Name
+			if t, ok := timers[path]; ok {
+				t.Stop()
+			}
+			timers[path] = time.AfterFunc(debounceDelay, func() {
+				select {
+				case ready <- path:
+				case <-ctx.Done():
+				}
+			})
+
+		case path := <-ready:
+			delete(timers, path)
+			info, err := os.Stat(path)
+			if err != nil {
+				// Removed or renamed before it settled
+				continue
+			}
+			if last, ok := written[path]; ok && sameVersion(last, info) {
+				continue
+			}
+
+			w := &fileOutput{}
+			w.err = safeProcessFile(path, true, w)
+			os.Stdout.Write(w.stdout.Bytes())
+			os.Stderr.Write(w.stderr.Bytes())
+			switch {
+			case w.err != nil:
+				fmt.Fprintln(os.Stderr, "Error:", w.err)
+			case !w.changed:
+				infof(os.Stderr, "%s is already in order\n", path)
+			}
+			if info, err := os.Stat(path); err == nil {
+				written[path] = info
+			}
+		}
+	}
+}
+
+// watchTree adds dir and its subdirectories to watcher, skipping the
+// directories a walk of the command arguments would skip.
+func watchTree(watcher *fsnotify.Watcher, dir string, ignore *gitignore) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to walk %s: %w", path, err)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && (skipDir(d.Name()) || ignore != nil && ignore.ignored(path, true)) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// watchedFile reports whether a change to path should be processed.
+func watchedFile(path string, ignore *gitignore) bool {
+	if !strings.HasSuffix(path, ".go") {
+		return false
+	}
+	if !includeTests && strings.HasSuffix(path, "_test.go") {
+		return false
+	}
+	return ignore == nil || !ignore.ignored(path, false)
+}
+
+// sameVersion reports whether a and b describe the same file contents as
+// far as size and modification time can tell.
+func sameVersion(a, b fs.FileInfo) bool {
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
@@ -0,0 +1,91 @@
+package reorder
+
+import (
+	"go/ast"
+	"sort"
+)
+
+// sortByCaller orders methods alphabetically, except that an unexported
+// method called through the receiver is placed right after the first
+// method that calls it, recursively. Methods that nothing calls, and all
+// exported methods, keep their alphabetical turn.
+//
+// This is the SortByCaller mode and is experimental.
+func sortByCaller(methods []Method) {
+	sort.Stable(ByName(methods))
+
+	byName := make(map[string]int, len(methods))
+	for i, m := range methods {
+		byName[m.decl.Name.Name] = i
+	}
+
+	callees := make([][]int, len(methods))
+	called := make([]bool, len(methods))
+	for i, m := range methods {
+		for _, name := range receiverCalls(m.decl) {
+			j, ok := byName[name]
+			if !ok || j == i || ast.IsExported(name) {
+				continue
+			}
+			callees[i] = append(callees[i], j)
+			called[j] = true
+		}
+	}
+
+	order := make([]Method, 0, len(methods))
+	visited := make([]bool, len(methods))
+	var visit func(int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		order = append(order, methods[i])
+		for _, j := range callees[i] {
+			visit(j)
+		}
+	}
+
+	for i := range methods {
+		if !called[i] {
+			visit(i)
+		}
+	}
+	// Helpers only reachable through a cycle
+	for i := range methods {
+		visit(i)
+	}
+
+	copy(methods, order)
+}
+
+// receiverCalls returns the names of methods called on the receiver of
+// decl, such as helper in "s.helper()", in order of first appearance.
+func receiverCalls(decl *ast.FuncDecl) []string {
+	if decl.Body == nil || decl.Recv == nil || len(decl.Recv.List) == 0 || len(decl.Recv.List[0].Names) == 0 {
+		return nil
+	}
+	recv := decl.Recv.List[0].Names[0].Name
+	if recv == "_" {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == recv && !seen[sel.Sel.Name] {
+			seen[sel.Sel.Name] = true
+			names = append(names, sel.Sel.Name)
+		}
+		return true
+	})
+	return names
+}
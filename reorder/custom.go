@@ -0,0 +1,55 @@
+package reorder
+
+import (
+	"strings"
+	"sync"
+)
+
+// CustomPrefix starts a sort mode that selects a registered comparator,
+// as in "custom:byreceiver".
+const CustomPrefix = "custom:"
+
+// Comparator reports whether a sorts before b.
+type Comparator func(a, b Method) bool
+
+var (
+	comparatorsMu sync.RWMutex
+	comparators   = make(map[string]Comparator)
+)
+
+// RegisterComparator makes less available as the sort mode
+// "custom:"+name. It is meant to be called from an init function; a
+// later registration under the same name replaces the earlier one.
+//
+//	func init() {
+//		reorder.RegisterComparator("short-names", func(a, b reorder.Method) bool {
+//			return len(a.Name()) < len(b.Name())
+//		})
+//	}
+func RegisterComparator(name string, less Comparator) {
+	comparatorsMu.Lock()
+	defer comparatorsMu.Unlock()
+	comparators[name] = less
+}
+
+// comparator returns the comparator selected by a custom sort mode.
+func comparator(mode string) (Comparator, bool) {
+	name, ok := strings.CutPrefix(mode, CustomPrefix)
+	if !ok {
+		return nil, false
+	}
+	comparatorsMu.RLock()
+	defer comparatorsMu.RUnlock()
+	less, ok := comparators[name]
+	return less, ok
+}
+
+// byComparator orders methods with a registered comparator.
+type byComparator struct {
+	methods []Method
+	less    Comparator
+}
+
+func (c byComparator) Len() int           { return len(c.methods) }
+func (c byComparator) Swap(i, j int)      { c.methods[i], c.methods[j] = c.methods[j], c.methods[i] }
+func (c byComparator) Less(i, j int) bool { return c.less(c.methods[i], c.methods[j]) }
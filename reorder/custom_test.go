@@ -0,0 +1,42 @@
+package reorder_test
+
+import (
+	"fmt"
+
+	"github.com/o4f6bgpac3/go-func-formatter/reorder"
+)
+
+func ExampleRegisterComparator() {
+	// Shorter names first, then by name
+	reorder.RegisterComparator("short-names", func(a, b reorder.Method) bool {
+		if len(a.Name()) != len(b.Name()) {
+			return len(a.Name()) < len(b.Name())
+		}
+		return a.Name() < b.Name()
+	})
+
+	src := `package p
+
+type T struct{}
+
+func (T) Close() {}
+
+func (T) Do() {}
+
+func (T) Abort() {}
+`
+	opts := reorder.DefaultOptions()
+	opts.SortMode = reorder.CustomPrefix + "short-names"
+	res, err := reorder.Process([]byte(src), "t.go", opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, m := range res.Moves {
+		fmt.Println(m.Method)
+	}
+	// Output:
+	// Do
+	// Abort
+	// Close
+}
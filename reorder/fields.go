@@ -0,0 +1,74 @@
+package reorder
+
+import (
+	"go/ast"
+	"slices"
+	"sort"
+)
+
+// sortByFieldOrder orders methods by the struct field each one mostly
+// works with, in the order the fields of its receiver type are declared in
+// fields. A method's field is the one its body selects most often on the
+// receiver, as in "s.count++". Methods where no single field dominates
+// follow, alphabetically.
+//
+// This is the SortByFieldOrder mode and is experimental. It ignores
+// Reverse.
+func sortByFieldOrder(methods []Method, fields map[string][]string) {
+	rank := make(map[*ast.FuncDecl]int, len(methods))
+	for _, m := range methods {
+		names := fields[m.recv]
+		rank[m.decl] = len(names)
+		if i := slices.Index(names, dominantField(m.decl, names)); i >= 0 {
+			rank[m.decl] = i
+		}
+	}
+	// Ranks of different receivers are compared as if they were one
+	// list; grouping by receiver keeps them apart
+	sort.SliceStable(methods, func(i, j int) bool {
+		ri, rj := rank[methods[i].decl], rank[methods[j].decl]
+		if ri != rj {
+			return ri < rj
+		}
+		return methods[i].decl.Name.Name < methods[j].decl.Name.Name
+	})
+}
+
+// dominantField returns the field of names that decl's body selects on
+// the receiver strictly more often than any other, or "" if there is no
+// such field.
+func dominantField(decl *ast.FuncDecl, names []string) string {
+	if decl.Body == nil || decl.Recv == nil || len(decl.Recv.List) == 0 || len(decl.Recv.List[0].Names) == 0 {
+		return ""
+	}
+	recv := decl.Recv.List[0].Names[0].Name
+	if recv == "_" {
+		return ""
+	}
+
+	counts := make(map[string]int)
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == recv && slices.Contains(names, sel.Sel.Name) {
+			counts[sel.Sel.Name]++
+		}
+		return true
+	})
+
+	best, top, tied := "", 0, false
+	for name, n := range counts {
+		switch {
+		case n > top:
+			best, top, tied = name, n, false
+		case n == top:
+			tied = true
+		}
+	}
+	if tied {
+		return ""
+	}
+	return best
+}
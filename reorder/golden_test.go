@@ -0,0 +1,94 @@
+package reorder
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the testdata/*.out.go golden files")
+
+// goldenVariants lists, by fixture name, more options to run a fixture
+// with besides the defaults. The result of variant v of testdata/x.in.go
+// is compared with testdata/x.v.out.go.
+var goldenVariants = map[string][]struct {
+	name string
+	set  func(*Options)
+}{
+	"constructors": {
+		{"group_constructors", func(o *Options) { o.IncludeFunctions, o.GroupConstructors = true, true }},
+	},
+	"new_anchor": {
+		{"minimal_diff", func(o *Options) { o.MinimalDiff = true }},
+	},
+	"shared_doc": {
+		{"reverse", func(o *Options) { o.Reverse = true }},
+		{"minimal_diff", func(o *Options) { o.MinimalDiff = true }},
+	},
+}
+
+// TestGolden runs every testdata/*.in.go file through Process with the
+// default options and compares the result with the matching *.out.go,
+// then does the same for each of its goldenVariants.
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.in.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata/*.in.go files")
+	}
+
+	for _, in := range inputs {
+		name := strings.TrimSuffix(filepath.Base(in), ".in.go")
+		t.Run(name, func(t *testing.T) {
+			testGolden(t, in, filepath.Join("testdata", name+".out.go"), DefaultOptions())
+		})
+		for _, v := range goldenVariants[name] {
+			t.Run(name+"/"+v.name, func(t *testing.T) {
+				opts := DefaultOptions()
+				v.set(&opts)
+				testGolden(t, in, filepath.Join("testdata", name+"."+v.name+".out.go"), opts)
+			})
+		}
+	}
+}
+
+// testGolden processes the file in with opts and compares the result with
+// the file golden, or writes it there with -update.
+func testGolden(t *testing.T, in, golden string, opts Options) {
+	src, err := os.ReadFile(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Process(src, in, opts)
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+
+	if *update {
+		if err := os.WriteFile(golden, res.Output, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(res.Output, want) {
+		t.Errorf("output differs from %s:\n%s", golden, res.Output)
+	}
+
+	// Sorted output stays as it is
+	again, err := Process(res.Output, golden, opts)
+	if err != nil {
+		t.Fatalf("Process() on the output error: %v", err)
+	}
+	if !bytes.Equal(again.Output, res.Output) {
+		t.Errorf("second run changes the output:\n%s", again.Output)
+	}
+}
@@ -0,0 +1,117 @@
+package reorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"slices"
+)
+
+// checkDeclarations verifies that out, the reassembled source before any
+// formatting, holds exactly the function and method declarations of src,
+// byte for byte, in any order. Reordering only ever moves whole
+// declarations, so a difference means the reassembly is broken and out
+// must not be written.
+func checkDeclarations(src, out []byte, filename string) error {
+	want, err := declarationSources(src, filename)
+	if err != nil {
+		return err
+	}
+	got, err := declarationSources(out, filename)
+	if err != nil {
+		return fmt.Errorf("reordered source for %s does not parse (this is a bug): %w", filename, err)
+	}
+
+	for decl, n := range want {
+		if got[decl] != n {
+			return fmt.Errorf("reordering changed a declaration in %s (this is a bug): %.60q", filename, decl)
+		}
+		delete(got, decl)
+	}
+	for decl := range got {
+		return fmt.Errorf("reordering produced an unexpected declaration in %s (this is a bug): %.60q", filename, decl)
+	}
+	return nil
+}
+
+// checkSpans verifies the spans the methods are cut out of src by before
+// they are written into their new slots. slots holds the methods in
+// source order, methods the same methods in their new order. Each span
+// must lie within src after the one before it and cover its whole
+// declaration, and the texts written into the slots, in the order of
+// methods, must be the texts cut out of them, each once. Anything else
+// means a boundary is off, and the reassembly, which keeps the text
+// between slots as it is, would drop or double bytes.
+func checkSpans(src []byte, fSet *token.FileSet, slots, methods []Method, filename string) error {
+	if len(slots) != len(methods) {
+		return fmt.Errorf("%d methods for %d slots in %s (this is a bug)", len(methods), len(slots), filename)
+	}
+	span := func(m Method) (start, end int) {
+		return fSet.Position(m.start).Offset, fSet.Position(m.end).Offset
+	}
+
+	cut := make([][]byte, 0, len(slots))
+	written := make([][]byte, 0, len(methods))
+	off := 0
+	for i, slot := range slots {
+		start, end := span(slot)
+		last := slot.decl
+		if len(slot.joined) > 0 {
+			last = slot.joined[len(slot.joined)-1]
+		}
+		declStart, declEnd := fSet.Position(slot.decl.Pos()).Offset, fSet.Position(last.End()).Offset
+		if start < off || start > declStart || declEnd > end || end > len(src) {
+			return fmt.Errorf("bad span %d-%d for method %s in %s (this is a bug)", start, end, slot.Name(), filename)
+		}
+		from, to := span(methods[i])
+		cut = append(cut, src[start:end])
+		written = append(written, src[from:to])
+		off = end
+	}
+
+	slices.SortFunc(cut, bytes.Compare)
+	slices.SortFunc(written, bytes.Compare)
+	if !slices.EqualFunc(cut, written, bytes.Equal) {
+		return fmt.Errorf("reordered methods do not match the methods of %s (this is a bug)", filename)
+	}
+	return nil
+}
+
+// checkParses verifies that out, the final result after formatting and
+// every other fix-up, is still valid Go, so that a broken reassembly is
+// never written over a working file. The error gives the position of the
+// first problem, but is not a parse error of the input.
+func checkParses(out []byte, filename string) error {
+	_, err := parser.ParseFile(token.NewFileSet(), filename, out, parser.SkipObjectResolution)
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		err = list[0]
+	}
+	if err != nil {
+		return fmt.Errorf("reordered source for %s does not parse (this is a bug): %v", filename, err)
+	}
+	return nil
+}
+
+// declarationSources counts the source text of each function and method
+// declaration in src, from the func keyword to the closing brace.
+func declarationSources(src []byte, filename string) (map[string]int, error) {
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]int)
+	for _, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			start := fSet.Position(funcDecl.Pos()).Offset
+			end := fSet.Position(funcDecl.End()).Offset
+			sources[string(src[start:end])]++
+		}
+	}
+	return sources, nil
+}
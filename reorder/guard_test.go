@@ -0,0 +1,145 @@
+package reorder
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const guardSrc = `package p
+
+type T struct{}
+
+func (T) B() {}
+
+func (T) A() {}
+`
+
+// guardMethods returns the methods of guardSrc in source order, each
+// spanning its declaration.
+func guardMethods(t *testing.T) (*token.FileSet, []Method) {
+	t.Helper()
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, "test.go", guardSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var methods []Method
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			methods = append(methods, Method{decl: fn, recv: "T", start: fn.Pos(), end: fn.End()})
+		}
+	}
+	return fSet, methods
+}
+
+func TestCheckSpans(t *testing.T) {
+	tests := []struct {
+		name string
+		// change edits the slots and the methods written into them
+		change  func(slots, methods []Method) ([]Method, []Method)
+		wantErr string
+	}{
+		{
+			name: "swapped",
+			change: func(slots, methods []Method) ([]Method, []Method) {
+				return slots, []Method{methods[1], methods[0]}
+			},
+		},
+		{
+			name: "written twice",
+			change: func(slots, methods []Method) ([]Method, []Method) {
+				return slots, []Method{methods[0], methods[0]}
+			},
+			wantErr: "do not match",
+		},
+		{
+			name: "missing method",
+			change: func(slots, methods []Method) ([]Method, []Method) {
+				return slots, methods[:1]
+			},
+			wantErr: "1 methods for 2 slots",
+		},
+		{
+			name: "span short of its declaration",
+			change: func(slots, methods []Method) ([]Method, []Method) {
+				slots[1].end--
+				return slots, methods
+			},
+			wantErr: "bad span",
+		},
+		{
+			name: "overlapping spans",
+			change: func(slots, methods []Method) ([]Method, []Method) {
+				slots[1].start = slots[0].end - 1
+				return slots, methods
+			},
+			wantErr: "bad span",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fSet, slots := guardMethods(t)
+			methods := append([]Method(nil), slots...)
+			slots, methods = tt.change(slots, methods)
+
+			err := checkSpans([]byte(guardSrc), fSet, slots, methods, "test.go")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkSpans() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "(this is a bug)") {
+				t.Errorf("checkSpans() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckDeclarations(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		wantErr string
+	}{
+		{"reordered", strings.Replace(guardSrc, "func (T) B() {}\n\nfunc (T) A() {}", "func (T) A() {}\n\nfunc (T) B() {}", 1), ""},
+		{"body changed", strings.Replace(guardSrc, "func (T) A() {}", "func (T) A() { println() }", 1), "changed a declaration"},
+		{"method dropped", strings.Replace(guardSrc, "func (T) A() {}\n", "", 1), "changed a declaration"},
+		{"method doubled", guardSrc + "\nfunc (T) A() {}\n", "changed a declaration"},
+		{"method added", guardSrc + "\nfunc (T) C() {}\n", "unexpected declaration"},
+		{"broken", guardSrc + "\nfunc (", "does not parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDeclarations([]byte(guardSrc), []byte(tt.out), "test.go")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkDeclarations() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "(this is a bug)") {
+				t.Errorf("checkDeclarations() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckParses(t *testing.T) {
+	if err := checkParses([]byte(guardSrc), "test.go"); err != nil {
+		t.Errorf("checkParses() error on valid source: %v", err)
+	}
+
+	// A reassembly that lost a closing brace
+	broken := strings.Replace(guardSrc, "func (T) B() {}", "func (T) B() {", 1)
+	err := checkParses([]byte(broken), "test.go")
+	if err == nil {
+		t.Fatal("checkParses() accepts a source that does not parse")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "test.go:") || !strings.Contains(msg, "(this is a bug)") || strings.Contains(msg, "\n") {
+		t.Errorf("checkParses() error = %q, want the first problem's position and a bug note", msg)
+	}
+}
@@ -0,0 +1,110 @@
+package reorder
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"strconv"
+)
+
+// buildHeader returns the leading block of src made of build constraint
+// lines (//go:build or // +build) and blank lines, up to and including
+// the last constraint line. It is empty when src has no such header.
+func buildHeader(src []byte) []byte {
+	end := 0
+	for off := 0; off < len(src); {
+		line, next := nextLine(src, off)
+		trimmed := bytes.TrimSpace(line)
+		if isConstraint(trimmed) {
+			end = next
+		} else if len(trimmed) != 0 {
+			break
+		}
+		off = next
+	}
+	return src[:end]
+}
+
+// keepBuildHeader makes sure the build constraints that opened src are
+// still at the very top of out, moving them back there if reassembly or
+// formatting relocated them.
+func keepBuildHeader(src, out []byte) []byte {
+	header := buildHeader(src)
+	if len(header) == 0 || len(buildHeader(out)) != 0 {
+		return out
+	}
+
+	// Drop the relocated constraints along with the blank line after them
+	var rest bytes.Buffer
+	dropped := false
+	for off := 0; off < len(out); {
+		line, next := nextLine(out, off)
+		trimmed := bytes.TrimSpace(line)
+		switch {
+		case isConstraint(trimmed):
+			dropped = true
+		case dropped && len(trimmed) == 0:
+			dropped = false
+		default:
+			dropped = false
+			rest.Write(out[off:next])
+		}
+		off = next
+	}
+
+	fixed := append([]byte(nil), header...)
+	fixed = append(fixed, '\n')
+	return append(fixed, bytes.TrimLeft(rest.Bytes(), "\r\n")...)
+}
+
+func isConstraint(line []byte) bool {
+	s := string(line)
+	return constraint.IsGoBuild(s) || constraint.IsPlusBuild(s)
+}
+
+// nextLine returns the line starting at off without its newline, and the
+// offset of the following line.
+func nextLine(src []byte, off int) ([]byte, int) {
+	i := bytes.IndexByte(src[off:], '\n')
+	if i < 0 {
+		return src[off:], len(src)
+	}
+	return src[off : off+i], off + i + 1
+}
+
+// cgoPreambles returns the comment above each import "C" in src, the cgo
+// preamble, exactly as written but for CRLF line endings, which gofmt
+// turns into LF anyway. It returns nil when there is none or src does not
+// parse.
+func cgoPreambles(src []byte) [][]byte {
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+
+	var preambles [][]byte
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			if path, _ := strconv.Unquote(imp.Path.Value); path != "C" {
+				continue
+			}
+			doc := imp.Doc
+			if doc == nil && !gen.Lparen.IsValid() {
+				doc = gen.Doc
+			}
+			if doc != nil {
+				text := src[fSet.Position(doc.Pos()).Offset:fSet.Position(doc.End()).Offset]
+				preambles = append(preambles, bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n")))
+			}
+		}
+	}
+	return preambles
+}
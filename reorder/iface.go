@@ -0,0 +1,102 @@
+package reorder
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// interfaceOrder returns the method names of the interface called name, in
+// declaration order. Embedded interfaces declared in the same file are
+// expanded in place. A package qualifier such as "io.Reader" is ignored,
+// only the final name is looked up.
+func interfaceOrder(file *ast.File, name string) ([]string, error) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	ifaces := make(map[string]*ast.InterfaceType)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if iface, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				ifaces[typeSpec.Name.Name] = iface
+			}
+		}
+	}
+
+	if _, ok := ifaces[name]; !ok {
+		return nil, fmt.Errorf("interface %s not found", name)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	var walk func(string)
+	walk = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		for _, field := range ifaces[name].Methods.List {
+			if len(field.Names) == 0 {
+				if ident, ok := field.Type.(*ast.Ident); ok && ifaces[ident.Name] != nil {
+					walk(ident.Name)
+				}
+				continue
+			}
+			for _, n := range field.Names {
+				names = append(names, n.Name)
+			}
+		}
+	}
+	walk(name)
+	return names, nil
+}
+
+// loadInterfaceOrder finds the interface named by opts.MatchInterface,
+// either in file or in opts.InterfaceSrc when that is set.
+func loadInterfaceOrder(file *ast.File, filename string, opts Options) ([]string, error) {
+	if opts.InterfaceSrc != nil {
+		filename = opts.InterfaceFile
+		var err error
+		file, err = parser.ParseFile(token.NewFileSet(), filename, opts.InterfaceSrc, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse interface file %s: %w", filename, err)
+		}
+	}
+
+	order, err := interfaceOrder(file, opts.MatchInterface)
+	if err != nil {
+		return nil, fmt.Errorf("%w in %s", err, filename)
+	}
+	return order, nil
+}
+
+// implements reports whether the receiver type of group implements the
+// interface whose methods are order. Without package information this
+// means the group declares every one of them.
+func implements(group []Method, order []string, pkg *PackageInfo) bool {
+	if len(group) == 0 || len(order) == 0 {
+		return false
+	}
+	if pkg != nil && pkg.Implementers != nil {
+		return pkg.Implementers[group[0].recv]
+	}
+
+	names := make(map[string]bool, len(group))
+	for _, m := range group {
+		names[m.decl.Name.Name] = true
+	}
+	for _, name := range order {
+		if !names[name] {
+			return false
+		}
+	}
+	return true
+}
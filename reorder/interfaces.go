@@ -0,0 +1,91 @@
+package reorder
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// sortInterfaces returns src with the methods of every interface type
+// sorted by name, for Options.SortInterfaces. Embedded interfaces and
+// type constraints move to the top of the interface, in their original
+// order. Doc and line comments move with their entry; other comments in
+// the interface body stay where they are. Interfaces nested in the
+// method signatures of another interface are left alone.
+func sortInterfaces(filename string, src []byte) ([]byte, error) {
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file %s: %w", filename, err)
+	}
+
+	var ifaces []*ast.InterfaceType
+	ast.Inspect(file, func(n ast.Node) bool {
+		iface, ok := n.(*ast.InterfaceType)
+		if !ok {
+			return true
+		}
+		ifaces = append(ifaces, iface)
+		return false
+	})
+
+	var b bytes.Buffer
+	off := 0
+	for _, iface := range ifaces {
+		fields := iface.Methods.List
+		if len(fields) < 2 {
+			continue
+		}
+
+		sorted := make([]*ast.Field, len(fields))
+		copy(sorted, fields)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			ei, ej := len(sorted[i].Names) == 0, len(sorted[j].Names) == 0
+			if ei || ej {
+				return ei && !ej
+			}
+			return sorted[i].Names[0].Name < sorted[j].Names[0].Name
+		})
+
+		// Blank space before each entry, to take along when it moves
+		before := make(map[*ast.Field][]byte, len(fields))
+		for i := 1; i < len(fields); i++ {
+			_, prevEnd := fieldSpan(fSet, fields[i-1])
+			start, _ := fieldSpan(fSet, fields[i])
+			if gap := src[prevEnd:start]; len(bytes.TrimSpace(gap)) == 0 {
+				before[fields[i]] = gap
+			}
+		}
+
+		// Each entry takes the place of the one it is sorted into
+		for i, slot := range fields {
+			start, _ := fieldSpan(fSet, slot)
+			gap := src[off:start]
+			if moved, ok := before[sorted[i]]; ok && i > 0 && len(bytes.TrimSpace(gap)) == 0 {
+				gap = moved
+			}
+			b.Write(gap)
+			from, to := fieldSpan(fSet, sorted[i])
+			b.Write(src[from:to])
+			_, off = fieldSpan(fSet, slot)
+		}
+	}
+	b.Write(src[off:])
+	return b.Bytes(), nil
+}
+
+// fieldSpan returns the offsets of an interface entry, including its doc
+// and line comments.
+func fieldSpan(fSet *token.FileSet, field *ast.Field) (start, end int) {
+	from, to := field.Pos(), field.End()
+	if field.Doc != nil {
+		from = field.Doc.Pos()
+	}
+	if field.Comment != nil {
+		to = field.Comment.End()
+	}
+	return fSet.Position(from).Offset, fSet.Position(to).Offset
+}
@@ -0,0 +1,46 @@
+package reorder
+
+import (
+	"testing"
+)
+
+func TestSortInterfaces(t *testing.T) {
+	const src = `package p
+
+type Store interface {
+	// Put stores v.
+	Put(k string, v int)
+	io.Closer
+
+	Get(k string) int // Get loads k.
+	// ops below
+	Delete(k string)
+	fmt.Stringer
+}
+
+type One interface{ Do() }
+`
+	const want = `package p
+
+type Store interface {
+	io.Closer
+	fmt.Stringer
+	// ops below
+	Delete(k string)
+
+	Get(k string) int // Get loads k.
+	// Put stores v.
+	Put(k string, v int)
+}
+
+type One interface{ Do() }
+`
+	opts := DefaultOptions()
+	opts.SortInterfaces = true
+	if got := process(t, src, opts); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := process(t, want, opts); got != want {
+		t.Errorf("sorted interfaces changed to\n%s", got)
+	}
+}
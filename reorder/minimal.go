@@ -0,0 +1,143 @@
+package reorder
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// reassembleMinimal builds the reordered source for Options.MinimalDiff.
+// posMethods are the methods in source order and methods the same
+// methods in their new order. The longest run of methods already in the
+// right relative order stays exactly where it is; every other method is
+// taken out of its place and reinserted just before the next method of
+// that run. Non-blank text between methods stays in place like in the
+// slot reassembly. Methods never leave the run of consecutive slots of
+// their scope, as given by scopeOf. It also returns how many methods were
+// moved.
+func reassembleMinimal(src []byte, fSet *token.FileSet, posMethods, methods []Method, scopeOf []int, sectionStart map[int]bool,
+	separator func(prev, next Method) string) ([]byte, int) {
+	newIndex := make(map[*ast.FuncDecl]int, len(methods))
+	for i, m := range methods {
+		newIndex[m.decl] = i
+	}
+	seq := make([]int, len(posMethods))
+	for i, m := range posMethods {
+		seq[i] = newIndex[m.decl]
+	}
+	// A method whose new slot is in another run cannot stay
+	runStart := make([]int, len(seq))
+	for i := range seq {
+		if i > 0 && scopeOf[i] == scopeOf[i-1] {
+			runStart[i] = runStart[i-1]
+		} else {
+			runStart[i] = i
+		}
+	}
+	var eligible, values []int
+	for i, v := range seq {
+		if runStart[v] == runStart[i] {
+			eligible = append(eligible, i)
+			values = append(values, v)
+		}
+	}
+	keep := make([]bool, len(seq))
+	for j, kept := range increasingSubsequence(values) {
+		keep[eligible[j]] = kept
+	}
+
+	// Moved methods leave their //go:generate lines behind, as in the
+	// slot reassembly
+	kept := make(map[*ast.FuncDecl]bool, len(posMethods))
+	for i, m := range posMethods {
+		kept[m.decl] = keep[i]
+	}
+	source := func(m Method) string {
+		if !kept[m.decl] {
+			_, rest := splitDirectives(src, fSet, m)
+			return rest
+		}
+		return string(src[fSet.Position(m.start).Offset:fSet.Position(m.end).Offset])
+	}
+
+	var b bytes.Buffer
+	var prev *Method
+	writeMethod := func(m Method) {
+		// Text kept in place brings its own trailing blank lines
+		if prev != nil {
+			b.WriteString(separator(*prev, m))
+		}
+		b.WriteString(source(m))
+		prev = &m
+	}
+	eol := lineEnding(src)
+
+	written := -1
+	prevEndOff := 0
+	moved := 0
+	for i, slot := range posMethods {
+		gap := src[prevEndOff:fSet.Position(slot.start).Offset]
+		prevEndOff = fSet.Position(slot.end).Offset
+		if i > 0 && runStart[i] == i {
+			// Finish the previous run before the text between runs
+			for _, m := range methods[written+1 : i] {
+				writeMethod(m)
+			}
+			written = i - 1
+		}
+		if i == 0 || !blankGap(gap) || sectionStart[i] {
+			b.Write(trimIndent(gap))
+			prev = nil
+		}
+		if !keep[i] {
+			moved++
+			if directives, _ := splitDirectives(src, fSet, slot); directives != "" {
+				if prev != nil {
+					b.WriteString(separator(*prev, slot))
+				}
+				b.WriteString(directives + eol)
+				prev = nil
+			}
+			continue
+		}
+		for _, m := range methods[written+1 : seq[i]+1] {
+			writeMethod(m)
+		}
+		written = seq[i]
+	}
+	for _, m := range methods[written+1:] {
+		writeMethod(m)
+	}
+	b.Write(src[prevEndOff:])
+	return b.Bytes(), moved
+}
+
+// increasingSubsequence marks the elements of one longest strictly
+// increasing subsequence of seq.
+func increasingSubsequence(seq []int) []bool {
+	// tails[k] is the index of the smallest element ending an increasing
+	// subsequence of length k+1 found so far
+	var tails []int
+	parent := make([]int, len(seq))
+	for i, v := range seq {
+		k := sort.Search(len(tails), func(k int) bool { return seq[tails[k]] >= v })
+		parent[i] = -1
+		if k > 0 {
+			parent[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	keep := make([]bool, len(seq))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = parent[i] {
+			keep[i] = true
+		}
+	}
+	return keep
+}
@@ -0,0 +1,1067 @@
+// Package reorder rearranges the method declarations of a Go source file.
+package reorder
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Method struct {
+	decl *ast.FuncDecl
+	// recv is the receiver type name, empty for a plain function
+	recv  string
+	start token.Pos
+	end   token.Pos
+	lines int
+	// order is the value of an //order:N directive, if ordered
+	order   int
+	ordered bool
+	// ctor marks a constructor function grouped with recv's methods
+	ctor bool
+	// deprecated marks a method whose doc has a Deprecated: paragraph,
+	// set only with Options.DeprecatedLast
+	deprecated bool
+	// joined holds the undocumented methods of the same receiver declared
+	// right after decl, without a blank line. decl's doc comment is
+	// taken to describe them too, so they move along with it.
+	joined []*ast.FuncDecl
+}
+
+// Name returns the method's name.
+func (m Method) Name() string { return m.decl.Name.Name }
+
+// Receiver returns the name of the receiver type, without any pointer or
+// type parameters. It is empty for a plain function, including a
+// constructor grouped with its type.
+func (m Method) Receiver() string {
+	if m.ctor {
+		return ""
+	}
+	return m.recv
+}
+
+// Lines returns the number of source lines the method spans, including
+// its doc comment.
+func (m Method) Lines() int { return m.lines }
+
+// Decl returns the method's declaration.
+func (m Method) Decl() *ast.FuncDecl { return m.decl }
+
+type Options struct {
+	// SortMode is one of SortModes, or CustomPrefix followed by the name
+	// of a registered comparator. The empty string means SortByName.
+	SortMode string
+	// KeepPrefixes lists method name prefixes left out of the reordering.
+	// An empty list lets every method take part.
+	KeepPrefixes []string
+	// OnlyReceiver, if set, restricts the reordering to methods of this
+	// receiver type, pointer or value. All other methods stay in place.
+	OnlyReceiver string
+	// Exclude, if set, matches method names left out of the reordering.
+	// Like methods with a kept prefix, excluded methods stay exactly where
+	// they are and the remaining methods are reordered around them.
+	Exclude *regexp.Regexp
+	// FirstLine and LastLine, if LastLine is set, restrict the reordering
+	// to methods lying entirely within these 1-based lines, doc comment
+	// included. All other methods stay in place.
+	FirstLine, LastLine int
+	// GroupByReceiver keeps methods of the same receiver type together.
+	GroupByReceiver bool
+	// WithinGroup, if set, is the sort mode used inside each receiver
+	// group instead of SortMode, such as SortByVisibility to list a
+	// type's exported methods before its helpers.
+	WithinGroup string
+	// GroupOrder is one of GroupOrders and orders the receiver groups.
+	// The empty string means GroupOrderName.
+	GroupOrder string
+	// ReceiverOrder, if set, lists receiver types whose groups come
+	// first, in this order, overriding GroupOrder. Other groups follow
+	// alphabetically; listed types without methods are ignored.
+	ReceiverOrder []string
+	// Reverse flips the order of the selected sort mode.
+	Reverse bool
+	// PairAccessors keeps GetX immediately before SetX when sorting by
+	// name.
+	PairAccessors bool
+	// FoldCase compares names case-insensitively when sorting by name,
+	// with or without PairAccessors.
+	FoldCase bool
+	// Priority lists method names that sort first, in this order.
+	Priority []string
+	// DeprecatedLast moves methods documented as deprecated, with a
+	// paragraph starting "Deprecated:", after the others of their group.
+	DeprecatedLast bool
+	// MatchInterface names an interface whose method order is imposed on
+	// every receiver type that declares all of its methods; other methods
+	// of such a type follow in the usual order. It implies
+	// GroupByReceiver.
+	MatchInterface string
+	// InterfaceSrc, if set, is the source of the file in which to look up
+	// MatchInterface instead of the file being reordered. InterfaceFile
+	// is its name, used in error messages.
+	InterfaceSrc  []byte
+	InterfaceFile string
+	// Package, if set, carries type information about the enclosing
+	// package and takes precedence over what a single file can tell.
+	Package *PackageInfo
+	// BlankLines is the number of blank lines written between two
+	// reordered methods. GroupBlankLines, when larger, is used instead
+	// between two receiver groups. gofmt collapses runs of blank lines to
+	// one, so BlankLines above 1 only survives with Format off, while
+	// GroupBlankLines is applied again after formatting.
+	BlankLines      int
+	GroupBlankLines int
+	// PreserveSections treats two or more blank lines between methods as
+	// a section boundary. Each section is sorted on its own and the
+	// boundary is kept as it was, also through formatting.
+	PreserveSections bool
+	// MinimalDiff keeps the longest run of methods that are already in
+	// order where they are and moves only the others, instead of filling
+	// the original method positions in the new order. The methods end up
+	// in the same order either way, but text between methods, such as a
+	// const block, may end up next to different methods.
+	MinimalDiff bool
+	// SortInterfaces also sorts the methods declared in interface types
+	// by name, after any embedded interfaces.
+	SortInterfaces bool
+	// NoComments parses without comments, which is faster. Each method
+	// then starts at its func keyword: doc and trailing comments stay
+	// where they were instead of moving with their method, and
+	// directives such as //order:N are not seen.
+	NoComments bool
+	// TrimTrailingSpace strips spaces and tabs from the end of every
+	// line of the result, except inside raw string literals.
+	TrimTrailingSpace bool
+	// IncludeFunctions also reorders plain functions. They are sorted as
+	// one block that takes the first slots, ahead of the methods. init
+	// functions run in source order and always stay in place.
+	IncludeFunctions bool
+	// TestOrder, if set, orders plain functions named after a symbol,
+	// such as TestFoo or BenchmarkT_M, like the symbols are listed here,
+	// usually by SymbolOrder on the file under test. Other functions
+	// follow by name.
+	TestOrder []string
+	// GroupConstructors, with IncludeFunctions, puts each constructor
+	// function New<T> right before the methods of type T, or in a group
+	// of its own if T has none here. T must be declared in, or have
+	// methods in, the file. It implies GroupByReceiver.
+	GroupConstructors bool
+	// Format runs the result through gofmt.
+	Format bool
+}
+
+// DefaultOptions returns the options used by the command line tool when
+// no flags are given.
+func DefaultOptions() Options {
+	return Options{
+		SortMode:        SortByName,
+		KeepPrefixes:    []string{"New"},
+		GroupByReceiver: true,
+		BlankLines:      1,
+		Format:          true,
+	}
+}
+
+type Result struct {
+	// Output is the reordered source. It equals the input when there is
+	// nothing to reorder.
+	Output []byte
+	// Methods is the number of methods that took part in the reordering.
+	Methods int
+	// Moved is the number of methods that changed position.
+	Moved int
+	// Moves lists every reordered method with its old and new index, in
+	// the new order.
+	Moves []Move
+	// Duplicates lists methods declared more than once on the same
+	// receiver type.
+	Duplicates []Duplicate
+	// MixedReceivers lists the types that have both pointer and value
+	// receivers, in order of first appearance.
+	MixedReceivers []MixedReceiver
+	// Warnings describes problems that did not stop the reordering, each
+	// prefixed with its file and line.
+	Warnings []string
+}
+
+// MixedReceiver names the methods of a type declared with pointer and
+// with value receivers.
+type MixedReceiver struct {
+	Type    string
+	Pointer []string
+	Value   []string
+}
+
+// Duplicate is a method whose receiver and name were already declared
+// earlier in the file.
+type Duplicate struct {
+	Receiver  string
+	Method    string
+	FirstLine int
+	Line      int
+}
+
+// PackageInfo carries facts about the enclosing package that a single
+// file cannot provide. It is filled in by callers that load type
+// information, such as the command's --package-mode.
+type PackageInfo struct {
+	// InterfaceMethods is the method order of Options.MatchInterface,
+	// which may then be declared in any file or imported package.
+	InterfaceMethods []string
+	// Implementers holds the receiver type names whose method sets
+	// implement Options.MatchInterface.
+	Implementers map[string]bool
+	// TypeOrder lists the package's type names in declaration order,
+	// across all of its files. It is used by GroupOrderDeclaration.
+	TypeOrder []string
+	// Fields maps each struct type name to its field names in
+	// declaration order. It is used by SortByFieldOrder.
+	Fields map[string][]string
+}
+
+// Move describes where a method went. Indexes count only the methods that
+// took part in the reordering.
+type Move struct {
+	Receiver string
+	Method   string
+	OldIndex int
+	NewIndex int
+	// Line is the line of the method's func keyword in the input.
+	Line int
+}
+
+// Reorder returns src with its methods reordered according to opts.
+func Reorder(src []byte, filename string, opts Options) ([]byte, error) {
+	res, err := Process(src, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	return res.Output, nil
+}
+
+// Process reorders the methods of src like Reorder and also reports what
+// was done.
+func Process(src []byte, filename string, opts Options) (Result, error) {
+	if opts.SortMode == "" {
+		opts.SortMode = SortByName
+	}
+	if !ValidSortMode(opts.SortMode) {
+		return Result{}, fmt.Errorf("invalid sort mode %q: must be one of %s, or %sNAME for a registered comparator", opts.SortMode, strings.Join(SortModes, ", "), CustomPrefix)
+	}
+	if opts.WithinGroup != "" && !ValidSortMode(opts.WithinGroup) {
+		return Result{}, fmt.Errorf("invalid within-group sort mode %q: must be one of %s, or %sNAME for a registered comparator", opts.WithinGroup, strings.Join(SortModes, ", "), CustomPrefix)
+	}
+	if (opts.SortMode == SortByFieldOrder || opts.WithinGroup == SortByFieldOrder) && (opts.Package == nil || opts.Package.Fields == nil) {
+		return Result{}, fmt.Errorf("sort mode %s needs the struct fields from Options.Package", SortByFieldOrder)
+	}
+	if opts.GroupOrder != "" && !slices.Contains(GroupOrders, opts.GroupOrder) {
+		return Result{}, fmt.Errorf("invalid group order %q: must be one of %s", opts.GroupOrder, strings.Join(GroupOrders, ", "))
+	}
+
+	if opts.SortInterfaces {
+		sorted, err := sortInterfaces(filename, src)
+		if err != nil {
+			return Result{}, err
+		}
+		src = sorted
+	}
+
+	mode := parser.ParseComments
+	if opts.NoComments {
+		mode = parser.SkipObjectResolution
+	}
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, filename, src, mode)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse file %s: %w", filename, err)
+	}
+
+	if opts.LastLine != 0 {
+		n := bytes.Count(src, []byte("\n"))
+		if !bytes.HasSuffix(src, []byte("\n")) {
+			n++
+		}
+		if opts.FirstLine < 1 || opts.FirstLine > opts.LastLine || opts.LastLine > n {
+			return Result{}, fmt.Errorf("invalid line range %d:%d for %s: must be within 1:%d", opts.FirstLine, opts.LastLine, filename, n)
+		}
+	}
+
+	// Separate methods vs others
+	var methods []Method
+
+	// End of the previous declaration when it was a method, used to find
+	// comments floating between two methods
+	prevMethodEnd := token.NoPos
+
+	// First line of each receiver and method name, to spot duplicates
+	declared := make(map[[2]string]int)
+	var duplicates []Duplicate
+
+	var warnings []string
+
+	// Types a constructor may be grouped with
+	var ctorTypes []string
+	if opts.IncludeFunctions && opts.GroupConstructors {
+		ctorTypes = constructorTypes(file)
+	}
+
+	// Pointer and value receiver methods of each type
+	receivers := make(map[string]*MixedReceiver)
+	var recvOrder []string
+
+	for i, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		isFunc := ok && funcDecl.Recv == nil
+		if !ok || isFunc && (!opts.IncludeFunctions || funcDecl.Name.Name == "init") {
+			prevMethodEnd = token.NoPos
+			continue
+		}
+
+		start := docStart(funcDecl)
+		if prevMethodEnd.IsValid() {
+			start = floatingCommentStart(file, prevMethodEnd, start)
+		}
+		next := token.NoPos
+		if i+1 < len(file.Decls) {
+			next = file.Decls[i+1].Pos()
+		}
+		end := trailingCommentEnd(fSet, file, funcDecl.End(), next)
+		prevMethodEnd = end
+
+		var recv string
+		if !isFunc {
+			recv = receiverType(funcDecl.Recv)
+			line := fSet.Position(funcDecl.Pos()).Line
+			key := [2]string{recv, funcDecl.Name.Name}
+			if first, ok := declared[key]; ok && funcDecl.Name.Name != "_" {
+				duplicates = append(duplicates, Duplicate{Receiver: recv, Method: funcDecl.Name.Name, FirstLine: first, Line: line})
+			} else {
+				declared[key] = line
+			}
+
+			r, ok := receivers[recv]
+			if !ok {
+				r = &MixedReceiver{Type: recv}
+				receivers[recv] = r
+				recvOrder = append(recvOrder, recv)
+			}
+			if isPointerReceiver(funcDecl.Recv) {
+				r.Pointer = append(r.Pointer, funcDecl.Name.Name)
+			} else {
+				r.Value = append(r.Value, funcDecl.Name.Name)
+			}
+		}
+
+		var ctor bool
+		if isFunc {
+			recv = constructedType(funcDecl.Name.Name, ctorTypes)
+			ctor = recv != ""
+		}
+
+		// Exclude constructors or funcs starting with a kept prefix
+		if !ctor && hasPrefix(funcDecl.Name.Name, opts.KeepPrefixes) && (isFunc || funcDecl.Recv.NumFields() > 0) {
+			continue
+		}
+		if opts.Exclude != nil && opts.Exclude.MatchString(funcDecl.Name.Name) {
+			continue
+		}
+		if hasIgnoreDirective(funcDecl.Doc) {
+			continue
+		}
+
+		if opts.OnlyReceiver != "" && recv != opts.OnlyReceiver {
+			continue
+		}
+
+		first, last := fSet.Position(start).Line, fSet.Position(end).Line
+		if opts.LastLine != 0 && (first < opts.FirstLine || last > opts.LastLine) {
+			continue
+		}
+		lines := last - first + 1
+
+		if n := len(methods); n > 0 && funcDecl.Doc == nil && sharesDoc(fSet, src, methods[n-1], file.Decls[i-1], recv, start) {
+			head := &methods[n-1]
+			head.joined = append(head.joined, funcDecl)
+			head.end = end
+			head.lines = last - fSet.Position(head.start).Line + 1
+			continue
+		}
+
+		m := Method{decl: funcDecl, recv: recv, start: start, end: end, lines: lines, ctor: ctor}
+		m.deprecated = opts.DeprecatedLast && isDeprecated(funcDecl.Doc)
+		if opts.SortMode == SortByAnnotation || opts.WithinGroup == SortByAnnotation {
+			var c *ast.Comment
+			m.order, m.ordered, c = orderDirective(funcDecl.Doc)
+			if c != nil && !m.ordered {
+				warnings = append(warnings, fmt.Sprintf("%s: invalid %s directive %q, expected an integer",
+					fSet.Position(c.Pos()), orderPrefix, c.Text))
+			}
+		}
+		methods = append(methods, m)
+	}
+
+	var mixed []MixedReceiver
+	for _, recv := range recvOrder {
+		if r := receivers[recv]; len(r.Pointer) > 0 && len(r.Value) > 0 {
+			mixed = append(mixed, *r)
+		}
+	}
+
+	// Comment association must not make two spans overlap, or the
+	// reassembly would duplicate or drop text. Should it ever happen,
+	// move the bare declarations and leave all comments in place.
+	if !spansOrdered(methods) {
+		for i, m := range methods {
+			last := m.decl
+			if len(m.joined) > 0 {
+				last = m.joined[len(m.joined)-1]
+			}
+			methods[i].start, methods[i].end = m.decl.Pos(), last.End()
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: overlapping comments around methods, moving declarations without their comments", filename))
+	}
+
+	if len(methods) == 0 {
+		return Result{Output: src, Duplicates: duplicates, MixedReceivers: mixed, Warnings: warnings}, nil
+	}
+
+	var ifaceOrder []string
+	if opts.MatchInterface != "" && opts.Package != nil {
+		ifaceOrder = opts.Package.InterfaceMethods
+	} else if opts.MatchInterface != "" {
+		ifaceOrder, err = loadInterfaceOrder(file, filename, opts)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	var typeOrder []string
+	if len(opts.ReceiverOrder) > 0 {
+		typeOrder = opts.ReceiverOrder
+	} else if opts.GroupOrder == GroupOrderDeclaration && opts.Package != nil {
+		typeOrder = opts.Package.TypeOrder
+	} else if opts.GroupOrder == GroupOrderDeclaration {
+		typeOrder = declaredTypes(file)
+	}
+
+	// Sort methods, grouped by receiver if requested, one scope at a
+	// time. Each scope then fills the positions its methods had.
+	grouped := opts.GroupByReceiver || ifaceOrder != nil || ctorTypes != nil
+	scopeOf, sectionStart := scopes(fSet, file, src, methods, opts)
+	members := make(map[int][]Method)
+	for i, m := range methods {
+		members[scopeOf[i]] = append(members[scopeOf[i]], m)
+	}
+	for id, scope := range members {
+		funcs, scope := splitFunctions(scope)
+		if opts.TestOrder != nil {
+			sortByTestOrder(funcs, opts.TestOrder)
+		} else {
+			sortMethods(funcs, opts)
+		}
+		if grouped {
+			scope = sortGrouped(scope, opts, ifaceOrder, typeOrder)
+		} else {
+			sortMethods(scope, opts)
+		}
+		members[id] = append(funcs, scope...)
+	}
+	sorted := make([]Method, len(methods))
+	for i, id := range scopeOf {
+		sorted[i], members[id] = members[id][0], members[id][1:]
+	}
+	methods = sorted
+
+	// The original method positions become the slots the sorted methods
+	// are written into
+	posMethods := append([]Method(nil), methods...)
+	sort.Sort(ByPos(posMethods))
+	if err := checkSpans(src, fSet, posMethods, methods, filename); err != nil {
+		return Result{}, err
+	}
+
+	// Get sorted sources. A method moving to another slot leaves its
+	// //go:generate lines behind, followed by a blank line so gofmt does
+	// not make them part of the doc of the method written there.
+	var sortedSources []string
+	eol := lineEnding(src)
+	for i, m := range methods {
+		if m.decl == posMethods[i].decl {
+			startOff := fSet.Position(m.start).Offset
+			endOff := fSet.Position(m.end).Offset
+			sortedSources = append(sortedSources, string(src[startOff:endOff]))
+			continue
+		}
+		directives, _ := splitDirectives(src, fSet, posMethods[i])
+		if directives != "" {
+			directives += eol
+		}
+		_, rest := splitDirectives(src, fSet, m)
+		sortedSources = append(sortedSources, directives+rest)
+	}
+
+	// Build new source. Whitespace between two slots is normalized to the
+	// separator; anything else found there, such as a const block or a
+	// plain function, is kept in place.
+	sep := strings.Repeat(eol, max(opts.BlankLines, 0)+1)
+	groupSep := strings.Repeat(eol, max(opts.GroupBlankLines, opts.BlankLines, 0)+1)
+	separator := func(prev, next Method) string {
+		if next.recv != prev.recv && (grouped || prev.recv == "") {
+			return groupSep
+		}
+		return sep
+	}
+
+	var out []byte
+	moved := countMoved(methods, posMethods)
+	if opts.MinimalDiff {
+		out, moved = reassembleMinimal(src, fSet, posMethods, methods, scopeOf, sectionStart, separator)
+	} else {
+		var newSrc bytes.Buffer
+		prevEndOff := 0
+		for i, slot := range posMethods {
+			startOff := fSet.Position(slot.start).Offset
+			gap := src[prevEndOff:startOff]
+			if i > 0 && blankGap(gap) && !sectionStart[i] {
+				newSrc.WriteString(separator(methods[i-1], methods[i]))
+			} else {
+				newSrc.Write(trimIndent(gap))
+			}
+			newSrc.WriteString(sortedSources[i])
+			prevEndOff = fSet.Position(slot.end).Offset
+		}
+		newSrc.Write(src[prevEndOff:])
+		out = newSrc.Bytes()
+	}
+
+	if err := checkDeclarations(src, out, filename); err != nil {
+		return Result{}, err
+	}
+
+	if opts.Format {
+		formatted, err := format.Source(out)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to format reordered source for %s (this is a bug): %w", filename, err)
+		}
+		// The cgo preamble is C, not Go; leave the file unformatted
+		// rather than reflow it
+		if preambles := cgoPreambles(src); slices.EqualFunc(preambles, cgoPreambles(formatted), bytes.Equal) {
+			out = formatted
+		}
+		if g := opts.GroupBlankLines; g > 1 && g > opts.BlankLines {
+			out = spaceGroups(out, filename, methods, grouped, g)
+		}
+		if len(sectionStart) > 0 {
+			out = spaceSections(src, out, fSet, filename, posMethods, sectionStart)
+		}
+	}
+
+	// Build constraints must stay above everything else
+	out = keepBuildHeader(src, out)
+
+	if opts.TrimTrailingSpace {
+		out = trimTrailingSpace(out)
+	}
+
+	// The last method may have ended the input without a newline
+	out = append(bytes.TrimRight(out, "\r\n"), '\n')
+
+	// gofmt and the header fix emit LF only, so restore CRLF files
+	if eol == "\r\n" {
+		out = bytes.ReplaceAll(out, []byte("\r\n"), []byte("\n"))
+		out = bytes.ReplaceAll(out, []byte("\n"), []byte("\r\n"))
+	}
+
+	if err := checkParses(out, filename); err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Output:         out,
+		Methods:        len(methods),
+		Moved:          moved,
+		Moves:          moves(fSet, methods, posMethods),
+		Duplicates:     duplicates,
+		MixedReceivers: mixed,
+		Warnings:       warnings,
+	}, nil
+}
+
+func moves(fSet *token.FileSet, sorted, byPos []Method) []Move {
+	oldIndex := make(map[*ast.FuncDecl]int, len(byPos))
+	for i, m := range byPos {
+		oldIndex[m.decl] = i
+	}
+
+	moves := make([]Move, len(sorted))
+	for i, m := range sorted {
+		moves[i] = Move{
+			Receiver: m.Receiver(),
+			Method:   m.decl.Name.Name,
+			OldIndex: oldIndex[m.decl],
+			NewIndex: i,
+			Line:     fSet.Position(m.decl.Pos()).Line,
+		}
+	}
+	return moves
+}
+
+// countMoved reports how many methods end up in a different slot than the
+// one they occupied originally.
+func countMoved(sorted, byPos []Method) int {
+	moved := 0
+	for i := range sorted {
+		if sorted[i].decl != byPos[i].decl {
+			moved++
+		}
+	}
+	return moved
+}
+
+// lineEnding returns the dominant line ending of src, "\r\n" or "\n".
+func lineEnding(src []byte) string {
+	crlf := bytes.Count(src, []byte("\r\n"))
+	if crlf > bytes.Count(src, []byte("\n"))-crlf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// ReceiverType returns the name of the type a method with receiver list
+// recv belongs to, without any pointer or type parameters, such as "T"
+// for (t *T[K]). It is empty for a plain function.
+func ReceiverType(recv *ast.FieldList) string { return receiverType(recv) }
+
+// receiverType returns the base type name of a method's receiver list.
+// Only the type is looked at, so blank or missing receiver names such as
+// "func (_ *T) M()" are fine, and an empty list yields "".
+func receiverType(recv *ast.FieldList) string {
+	if recv == nil || len(recv.List) == 0 {
+		return ""
+	}
+	return receiverTypeName(recv.List[0].Type)
+}
+
+func isPointerReceiver(recv *ast.FieldList) bool {
+	if recv == nil || len(recv.List) == 0 {
+		return false
+	}
+	expr := recv.List[0].Type
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+	_, ok := expr.(*ast.StarExpr)
+	return ok
+}
+
+// receiverTypeName returns the base type name of a receiver, so that
+// pointer and value receivers of the same type share a name.
+//
+// Generic receivers such as *List[T] or Map[K, V] yield the base type
+// name, and parentheses, as in (*T), are looked through. A qualified
+// name such as *pkg.T, which the parser accepts although the compiler
+// does not, keeps its qualifier.
+func receiverTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			if pkg := receiverTypeName(e.X); pkg != "" {
+				return pkg + "." + e.Sel.Name
+			}
+			return e.Sel.Name
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// scopes assigns each of methods, in source order, the scope it is
+// sorted in. Methods inside a //region ... //endregion block form a scope
+// of their own, and so do those outside any region. Only markers on a line
+// of their own between declarations count. With
+// opts.PreserveSections, two or more blank lines also start a new scope;
+// sectionStart holds the indexes of the methods right after such a break.
+func scopes(fSet *token.FileSet, file *ast.File, src []byte, methods []Method, opts Options) (scopeOf []int, sectionStart map[int]bool) {
+	// Region of each method, 0 outside any region. Regions may nest.
+	var markers []*ast.Comment
+	for _, cg := range file.Comments {
+		if inDecl(file, cg.Pos()) {
+			continue
+		}
+		for _, c := range cg.List {
+			if _, ok := regionMarker(c); ok && ownLine(fSet, src, c) {
+				markers = append(markers, c)
+			}
+		}
+	}
+	region := make([]int, len(methods))
+	var open []int
+	regions, next := 0, 0
+	for i, m := range methods {
+		for ; next < len(markers) && markers[next].Pos() < m.decl.Pos(); next++ {
+			if opening, _ := regionMarker(markers[next]); opening {
+				regions++
+				open = append(open, regions)
+			} else if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+		if len(open) > 0 {
+			region[i] = open[len(open)-1]
+		}
+	}
+
+	sectionStart = make(map[int]bool)
+	section := make([]int, len(methods))
+	for i := 1; i < len(methods); i++ {
+		section[i] = section[i-1]
+		if !opts.PreserveSections {
+			continue
+		}
+		gap := src[fSet.Position(methods[i-1].end).Offset:fSet.Position(methods[i].start).Offset]
+		if blankGap(gap) && bytes.Count(gap, []byte("\n")) > 2 {
+			section[i]++
+			sectionStart[i] = true
+		}
+	}
+
+	ids := make(map[[2]int]int)
+	scopeOf = make([]int, len(methods))
+	for i := range methods {
+		key := [2]int{region[i], section[i]}
+		id, ok := ids[key]
+		if !ok {
+			id = len(ids)
+			ids[key] = id
+		}
+		scopeOf[i] = id
+	}
+	return scopeOf, sectionStart
+}
+
+// inDecl reports whether pos lies inside one of the declarations of file,
+// such as a comment in a function body.
+func inDecl(file *ast.File, pos token.Pos) bool {
+	i := sort.Search(len(file.Decls), func(i int) bool { return file.Decls[i].End() > pos })
+	return i < len(file.Decls) && file.Decls[i].Pos() <= pos
+}
+
+// ownLine reports whether nothing but indentation comes before c on its
+// line.
+func ownLine(fSet *token.FileSet, src []byte, c *ast.Comment) bool {
+	offset := fSet.Position(c.Pos()).Offset
+	lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+	return len(bytes.TrimLeft(src[lineStart:offset], " \t")) == 0
+}
+
+// blankGap reports whether gap, the text between two methods, holds
+// nothing but whitespace and semicolons separating declarations, so it
+// can be replaced by the separator.
+func blankGap(gap []byte) bool {
+	return len(bytes.Trim(gap, " \t\r\n;")) == 0
+}
+
+// spansOrdered reports whether the spans of methods, in source order,
+// are non-empty and do not overlap.
+func spansOrdered(methods []Method) bool {
+	for i, m := range methods {
+		if m.start >= m.end || i > 0 && m.start < methods[i-1].end {
+			return false
+		}
+	}
+	return true
+}
+
+// trimIndent drops the spaces and tabs that end gap after its last
+// newline. They indent whatever comes next, and a slot always starts at
+// the doc comment or func keyword, so a method moved there would keep the
+// indentation of the one it replaced.
+func trimIndent(gap []byte) []byte {
+	i := bytes.LastIndexByte(gap, '\n')
+	if i >= 0 && len(bytes.TrimLeft(gap[i+1:], " \t")) == 0 {
+		return gap[:i+1]
+	}
+	return gap
+}
+
+// constructorTypes returns the types declared in file or used as a method
+// receiver there.
+func constructorTypes(file *ast.File) []string {
+	types := declaredTypes(file)
+	for _, decl := range file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Recv != nil {
+			if recv := receiverType(funcDecl.Recv); !slices.Contains(types, recv) {
+				types = append(types, recv)
+			}
+		}
+	}
+	return types
+}
+
+// constructedType returns the type a function called New<T> constructs,
+// choosing the longest such T in types, so NewServerConfig goes with
+// ServerConfig rather than Server. It is empty if there is none.
+func constructedType(name string, types []string) string {
+	rest, ok := strings.CutPrefix(name, "New")
+	if !ok {
+		return ""
+	}
+	var found string
+	for _, t := range types {
+		if strings.HasPrefix(rest, t) && len(t) > len(found) {
+			found = t
+		}
+	}
+	return found
+}
+
+// splitFunctions separates the plain functions from the methods, keeping
+// the order of each.
+func splitFunctions(methods []Method) (funcs, rest []Method) {
+	for _, m := range methods {
+		if m.recv == "" {
+			funcs = append(funcs, m)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+	return funcs, rest
+}
+
+// trailingCommentEnd extends end past a comment that starts on the same
+// line, such as "} // end of Foo" or "} //nolint:unused", so the comment
+// moves with its method. Comments after the opening brace are part of the
+// body and move anyway. A comment after next, the start of the following
+// declaration when it shares the line, belongs to that declaration.
+func trailingCommentEnd(fSet *token.FileSet, file *ast.File, end, next token.Pos) token.Pos {
+	line := fSet.Position(end).Line
+	for _, cg := range file.Comments {
+		if cg.Pos() < end {
+			continue
+		}
+		if next.IsValid() && cg.Pos() > next || slices.ContainsFunc(cg.List, isPinnedComment) {
+			break
+		}
+		if fSet.Position(cg.Pos()).Line == line {
+			return cg.End()
+		}
+		break
+	}
+	return end
+}
+
+// docStart returns where a method starts including its doc comment.
+// //go:generate lines in the doc are part of the method's span but do
+// not move with it; see splitDirectives.
+func docStart(funcDecl *ast.FuncDecl) token.Pos {
+	if funcDecl.Doc == nil {
+		return funcDecl.Pos()
+	}
+	start := token.NoPos
+	for _, c := range funcDecl.Doc.List {
+		if _, ok := regionMarker(c); ok {
+			// Region markers stay put; the doc is what follows them
+			start = token.NoPos
+		} else if !start.IsValid() {
+			start = c.Pos()
+		}
+	}
+	if !start.IsValid() {
+		return funcDecl.Pos()
+	}
+	return start
+}
+
+// splitDirectives splits the text of m into the //go:generate lines of
+// its doc comment and the rest. Generate directives run in file order, so
+// when m moves they stay in its slot and the text written elsewhere is
+// rest. A bare "//" line that gofmt puts before trailing directives goes
+// with them.
+func splitDirectives(src []byte, fSet *token.FileSet, m Method) (directives, rest string) {
+	start, end := fSet.Position(m.start).Offset, fSet.Position(m.end).Offset
+	if m.decl.Doc == nil {
+		return "", string(src[start:end])
+	}
+
+	var cut []*ast.Comment
+	list := m.decl.Doc.List
+	for i, c := range list {
+		if c.Pos() < m.start {
+			continue
+		}
+		if isGenerateDirective(c) {
+			cut = append(cut, c)
+		} else if c.Text == "//" && slices.ContainsFunc(list[i+1:], isGenerateDirective) &&
+			!slices.ContainsFunc(list[i+1:], func(c *ast.Comment) bool { return c.Text != "//" && !isGenerateDirective(c) }) {
+			cut = append(cut, c)
+		}
+	}
+	if !slices.ContainsFunc(cut, isGenerateDirective) {
+		return "", string(src[start:end])
+	}
+
+	var d, r strings.Builder
+	off := start
+	for _, c := range cut {
+		cStart := fSet.Position(c.Pos()).Offset
+		lineStart := max(bytes.LastIndexByte(src[:cStart], '\n')+1, start)
+		lineEnd := fSet.Position(c.End()).Offset
+		if i := bytes.IndexByte(src[lineEnd:], '\n'); i >= 0 {
+			lineEnd += i + 1
+		}
+		r.Write(src[off:lineStart])
+		if isGenerateDirective(c) {
+			d.Write(src[cStart:lineEnd])
+		}
+		off = lineEnd
+	}
+	r.Write(src[off:end])
+	return d.String(), r.String()
+}
+
+// floatingCommentStart moves start back to the first comment that lies
+// after prevEnd, so that free-floating comments between two methods, such
+// as "// region: serialization", travel with the method that follows them.
+// Comments at or before a //go:generate directive or a region marker stay
+// where they are.
+func floatingCommentStart(file *ast.File, prevEnd, start token.Pos) token.Pos {
+	floating := start
+	for _, cg := range file.Comments {
+		if cg.Pos() < prevEnd || cg.End() > start {
+			continue
+		}
+		if slices.ContainsFunc(cg.List, isPinnedComment) {
+			floating = start
+		} else if floating == start {
+			floating = cg.Pos()
+		}
+	}
+	return floating
+}
+
+func isGenerateDirective(c *ast.Comment) bool {
+	return strings.HasPrefix(c.Text, "//go:generate")
+}
+
+// sharesDoc reports whether a method of recv starting at start belongs
+// with head, the method before it: head is the declaration just before,
+// prev, or ends with it, has a doc comment and the same receiver, and
+// nothing but a line break separates the two.
+func sharesDoc(fSet *token.FileSet, src []byte, head Method, prev ast.Decl, recv string, start token.Pos) bool {
+	last := head.decl
+	if len(head.joined) > 0 {
+		last = head.joined[len(head.joined)-1]
+	}
+	if last != prev || head.decl.Doc == nil || head.recv != recv || head.ctor {
+		return false
+	}
+	gap := src[fSet.Position(head.end).Offset:fSet.Position(start).Offset]
+	return len(bytes.TrimSpace(gap)) == 0 && bytes.Count(gap, []byte("\n")) == 1
+}
+
+// isDeprecated reports whether doc has a paragraph starting with
+// "Deprecated:", the convention for marking deprecated identifiers.
+func isDeprecated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, para := range strings.Split(doc.Text(), "\n\n") {
+		if strings.HasPrefix(strings.TrimSpace(para), "Deprecated:") {
+			return true
+		}
+	}
+	return false
+}
+
+// regionMarker reports whether c is an editor folding marker, //region or
+// //#region opening a region and //endregion or //#endregion closing one.
+// gofmt turns markers in doc comments into "// region", which counts too.
+func regionMarker(c *ast.Comment) (opening, ok bool) {
+	text, found := strings.CutPrefix(c.Text, "//")
+	if !found {
+		return false, false
+	}
+	text = strings.TrimPrefix(strings.TrimPrefix(text, " "), "#")
+	for _, marker := range []string{"region", "endregion"} {
+		if rest, found := strings.CutPrefix(text, marker); found && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return marker == "region", true
+		}
+	}
+	return false, false
+}
+
+// isPinnedComment reports whether c must stay where it is in the file.
+func isPinnedComment(c *ast.Comment) bool {
+	_, marker := regionMarker(c)
+	return marker || isGenerateDirective(c)
+}
+
+// ignoreDirective pins a method in place when found in its doc comment.
+const ignoreDirective = "//reordertool:ignore"
+
+func hasIgnoreDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.HasPrefix(c.Text, ignoreDirective) {
+			return true
+		}
+	}
+	return false
+}
+
+// orderPrefix starts the directive giving a method's position for
+// SortByAnnotation, as in //order:10.
+const orderPrefix = "//order:"
+
+// orderDirective returns the value of the first //order:N directive in
+// doc. The comment is returned even when N is not an integer, in which
+// case ok is false.
+func orderDirective(doc *ast.CommentGroup) (n int, ok bool, c *ast.Comment) {
+	if doc == nil {
+		return 0, false, nil
+	}
+	for _, c := range doc.List {
+		if value, found := strings.CutPrefix(c.Text, orderPrefix); found {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			return n, err == nil, c
+		}
+	}
+	return 0, false, nil
+}
+
+// hasPrefix reports whether name starts with one of prefixes. An empty
+// prefix, as left by "--keep-prefix=New," on the command line, matches
+// nothing rather than everything.
+func hasPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
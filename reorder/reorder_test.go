@@ -0,0 +1,697 @@
+package reorder
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// process runs Process on src with opts and returns the output.
+func process(t *testing.T, src string, opts Options) string {
+	t.Helper()
+	res, err := Process([]byte(src), "test.go", opts)
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+	return string(res.Output)
+}
+
+func TestPreserveSections(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) D() {}
+
+func (T) C() {}
+
+
+// B is in the second section.
+func (T) B() {}
+
+func (T) A() {}
+
+
+
+func (T) Z() {}
+func (T) Y() {}
+`
+	const want = `package p
+
+type T struct{}
+
+func (T) C() {}
+
+func (T) D() {}
+
+
+func (T) A() {}
+
+// B is in the second section.
+func (T) B() {}
+
+
+
+func (T) Y() {}
+
+func (T) Z() {}
+`
+	for _, format := range []bool{true, false} {
+		for _, minimal := range []bool{false, true} {
+			opts := DefaultOptions()
+			opts.PreserveSections, opts.Format, opts.MinimalDiff = true, format, minimal
+			got := process(t, src, opts)
+			if format && got != want {
+				t.Errorf("Format %v, MinimalDiff %v: got\n%s\nwant\n%s", format, minimal, got, want)
+			}
+			if again := process(t, got, opts); again != got {
+				t.Errorf("Format %v, MinimalDiff %v: second run gives\n%s\nafter\n%s", format, minimal, again, got)
+			}
+		}
+	}
+}
+
+func TestGenericReceivers(t *testing.T) {
+	const src = `package p
+
+type List[T any] struct{}
+type Map[K comparable, V any] struct{}
+
+func (m Map[K, V]) Get(k K) V { var v V; return v }
+func (l *List[T]) Push(v T)   {}
+func (m *Map[K, V]) Delete(k K) {}
+func (l List[T]) Len() int    { return 0 }
+func (l *List[T]) Clear()     {}
+`
+	got := funcOrder(t, src, DefaultOptions())
+	want := []string{"Clear", "Len", "Push", "Delete", "Get"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestTrailingComments(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) B() {} // B's line comment
+
+// A documents A.
+func (T) A() {} // A's line comment
+
+// end of methods
+`
+	const want = `package p
+
+type T struct{}
+
+// A documents A.
+func (T) A() {} // A's line comment
+
+func (T) B() {} // B's line comment
+
+// end of methods
+`
+	got := process(t, src, DefaultOptions())
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if again := process(t, want, DefaultOptions()); again != want {
+		t.Errorf("sorted input changed to\n%s", again)
+	}
+}
+
+func TestInterleavedDeclarations(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) C() {}
+
+const limit = 3
+
+func helper() {}
+
+func (T) B() {}
+
+var x = 1
+
+func (T) A() {}
+`
+	const want = `package p
+
+type T struct{}
+
+func (T) A() {}
+
+const limit = 3
+
+func helper() {}
+
+func (T) B() {}
+
+var x = 1
+
+func (T) C() {}
+`
+	got := process(t, src, DefaultOptions())
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if again := process(t, got, DefaultOptions()); again != got {
+		t.Errorf("second run gives\n%s", again)
+	}
+}
+
+func TestCRLF(t *testing.T) {
+	src := strings.ReplaceAll(`package p
+
+type T struct{}
+
+// B documents B.
+func (T) B() {
+	println("b")
+}
+
+func (T) A() {}
+`, "\n", "\r\n")
+	for _, format := range []bool{true, false} {
+		opts := DefaultOptions()
+		opts.Format = format
+		got := process(t, src, opts)
+		if strings.Count(got, "\n") != strings.Count(got, "\r\n") {
+			t.Errorf("Format %v: result has lone line feeds: %q", format, got)
+		}
+		if !strings.Contains(got, "func (T) A() {}\r\n\r\n// B documents B.") {
+			t.Errorf("Format %v: methods not reordered: %q", format, got)
+		}
+	}
+}
+
+func TestFloatingComments(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) Marshal() {}
+
+// region: serialization
+
+func (T) Decode() {}
+
+// Close documents Close.
+func (T) Close() {}
+`
+	const want = `package p
+
+type T struct{}
+
+// Close documents Close.
+func (T) Close() {}
+
+// region: serialization
+
+func (T) Decode() {}
+
+func (T) Marshal() {}
+`
+	got := process(t, src, DefaultOptions())
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if strings.Count(got, "// region: serialization") != 1 {
+		t.Errorf("floating comment lost or doubled")
+	}
+}
+
+func TestGenerateDirectives(t *testing.T) {
+	const directive = "//go:generate mockgen -source=t.go -destination=mock_t.go"
+	tests := []struct {
+		name string
+		src  string
+		want string
+		// wantMinimal is the result with MinimalDiff, if different
+		wantMinimal string
+	}{
+		{
+			name: "before the doc",
+			src: `package p
+
+type T struct{}
+
+// B does b.
+func (T) B() {}
+
+` + directive + `
+// A does a.
+func (T) A() {}
+`,
+			want: `package p
+
+type T struct{}
+
+// A does a.
+func (T) A() {}
+
+` + directive + `
+
+// B does b.
+func (T) B() {}
+`,
+			// A stays in place and its directive with it
+			wantMinimal: `package p
+
+type T struct{}
+
+// A does a.
+//
+` + directive + `
+func (T) A() {}
+
+// B does b.
+func (T) B() {}
+`,
+		},
+		{
+			name: "after the doc",
+			src: `package p
+
+type T struct{}
+
+// B does b.
+//
+` + directive + `
+func (T) B() {}
+
+// A does a.
+func (T) A() {}
+`,
+			want: `package p
+
+type T struct{}
+
+` + directive + `
+
+// A does a.
+func (T) A() {}
+
+// B does b.
+func (T) B() {}
+`,
+		},
+	}
+	for _, tt := range tests {
+		for _, minimal := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s minimal %v", tt.name, minimal), func(t *testing.T) {
+				opts := DefaultOptions()
+				opts.MinimalDiff = minimal
+				want := tt.want
+				if minimal && tt.wantMinimal != "" {
+					want = tt.wantMinimal
+				}
+				got := process(t, tt.src, opts)
+				if got != want {
+					t.Errorf("got\n%s\nwant\n%s", got, want)
+				}
+				if second := process(t, got, opts); second != got {
+					t.Errorf("second run gives\n%s", second)
+				}
+			})
+		}
+	}
+}
+
+func TestIgnoreDirective(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) D() {}
+
+func (T) C() {}
+
+// B stays here.
+//
+//reordertool:ignore
+func (T) B() {}
+
+func (T) E() {}
+
+func (T) A() {}
+`
+	got := funcOrder(t, src, DefaultOptions())
+	want := []string{"A", "C", "B", "D", "E"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestBlankReceiverNames(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (_ *T) M() {}
+
+func (T) L() {}
+
+func (*T) K() {}
+`
+	got := funcOrder(t, src, DefaultOptions())
+	want := []string{"K", "L", "M"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestNoFinalNewline(t *testing.T) {
+	const src = "package p\n\ntype T struct{}\n\nfunc (T) B() {}\n\nfunc (T) A() {}"
+	const want = "package p\n\ntype T struct{}\n\nfunc (T) A() {}\n\nfunc (T) B() {}\n"
+	for _, format := range []bool{true, false} {
+		opts := DefaultOptions()
+		opts.Format = format
+		if got := process(t, src, opts); got != want {
+			t.Errorf("Format %v: got %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestOddIndentation(t *testing.T) {
+	const src = "package p\n\ntype T struct{}\n\n\t// B is documented with a tab.\nfunc (T) B() {}\n\n  // A is documented with spaces.\nfunc (T) A() {}\n"
+	const want = "package p\n\ntype T struct{}\n\n// A is documented with spaces.\nfunc (T) A() {}\n\n// B is documented with a tab.\nfunc (T) B() {}\n"
+	for _, format := range []bool{true, false} {
+		opts := DefaultOptions()
+		opts.Format = format
+		got := process(t, src, opts)
+		if got != want {
+			t.Errorf("Format %v: got %q, want %q", format, got, want)
+		}
+		if again := process(t, got, opts); again != got {
+			t.Errorf("Format %v: second run gives %q", format, again)
+		}
+	}
+}
+
+func TestReceiverType(t *testing.T) {
+	tests := []struct {
+		recv string
+		want string
+	}{
+		{"t T", "T"},
+		{"t *T", "T"},
+		{"*T", "T"},
+		{"t *pkg.T", "pkg.T"},
+		{"m T[K, V]", "T"},
+		{"m *T[K]", "T"},
+		{"t (*T)", "T"},
+		{"\n\ts *Server,\n", "Server"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.recv, func(t *testing.T) {
+			src := "package p\n\nfunc (" + tt.recv + ") M() {}\n"
+			file, err := parser.ParseFile(token.NewFileSet(), "test.go", src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decl := file.Decls[0].(*ast.FuncDecl)
+			if got := ReceiverType(decl.Recv); got != tt.want {
+				t.Errorf("ReceiverType(%s) = %q, want %q", tt.recv, got, tt.want)
+			}
+		})
+	}
+	if got := ReceiverType(nil); got != "" {
+		t.Errorf("ReceiverType(nil) = %q, want \"\"", got)
+	}
+}
+
+func TestNolintDirectives(t *testing.T) {
+	const src = `package p
+
+type S struct{}
+
+func (s *S) M() { //nolint:gocyclo
+	println()
+}
+
+//nolint:unused
+func (s *S) L() {}
+
+func (s *S) K() {} //nolint:revive
+`
+	const want = `package p
+
+type S struct{}
+
+func (s *S) K() {} //nolint:revive
+
+//nolint:unused
+func (s *S) L() {}
+
+func (s *S) M() { //nolint:gocyclo
+	println()
+}
+`
+	if got := process(t, src, DefaultOptions()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestKeepPrefixAnchors(t *testing.T) {
+	const src = `package p
+
+type Thing struct{}
+
+func (t *Thing) Zap() {}
+
+func (t *Thing) NewThing() *Thing { return &Thing{} }
+
+func (t *Thing) Bar() {}
+
+func (t *Thing) Foo() {}
+`
+	tests := []struct {
+		name     string
+		prefixes []string
+		want     []string
+	}{
+		{"New stays", []string{"New"}, []string{"Bar", "NewThing", "Foo", "Zap"}},
+		{"no prefixes", nil, []string{"Bar", "Foo", "NewThing", "Zap"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.KeepPrefixes = tt.prefixes
+			if got := funcOrder(t, src, opts); !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSharedLines(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) C() {} // C's comment
+func (T) B() {}; func (T) A() {} // A's comment
+
+// D documents D.
+func (T) D() {}
+`
+	for _, minimal := range []bool{false, true} {
+		opts := DefaultOptions()
+		opts.MinimalDiff = minimal
+		got := funcOrder(t, src, opts)
+		if want := []string{"A", "B", "C", "D"}; !slices.Equal(got, want) {
+			t.Errorf("MinimalDiff %v: order = %v, want %v", minimal, got, want)
+		}
+		out := process(t, src, opts)
+		for _, comment := range []string{"// C's comment", "// A's comment", "// D documents D."} {
+			if n := strings.Count(out, comment); n != 1 {
+				t.Errorf("MinimalDiff %v: %q appears %d times:\n%s", minimal, comment, n, out)
+			}
+		}
+		if !strings.Contains(out, "func (T) A() {} // A's comment") {
+			t.Errorf("MinimalDiff %v: A's comment left A:\n%s", minimal, out)
+		}
+	}
+}
+
+func TestSpansOrdered(t *testing.T) {
+	span := func(start, end token.Pos) Method { return Method{start: start, end: end} }
+	tests := []struct {
+		name    string
+		methods []Method
+		want    bool
+	}{
+		{"apart", []Method{span(1, 5), span(7, 9)}, true},
+		{"touching", []Method{span(1, 5), span(5, 9)}, true},
+		{"overlapping", []Method{span(1, 6), span(5, 9)}, false},
+		{"inverted", []Method{span(1, 5), span(9, 7)}, false},
+		{"empty", []Method{span(3, 3)}, false},
+	}
+	for _, tt := range tests {
+		if got := spansOrdered(tt.methods); got != tt.want {
+			t.Errorf("%s: spansOrdered() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRegions(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) Z() {}
+
+// region Reading
+func (T) Read() {}
+
+func (T) Peek() {}
+//endregion
+
+func (T) A() {}
+
+// #region Writing
+func (T) Write() {}
+
+func (T) Flush() {}
+// #endregion
+`
+	const want = `package p
+
+type T struct{}
+
+func (T) A() {}
+
+// region Reading
+func (T) Peek() {}
+
+func (T) Read() {}
+
+//endregion
+
+func (T) Z() {}
+
+// #region Writing
+func (T) Flush() {}
+
+func (T) Write() {}
+
+// #endregion
+`
+	for _, minimal := range []bool{false, true} {
+		opts := DefaultOptions()
+		opts.MinimalDiff = minimal
+		got := process(t, src, opts)
+		if got != want {
+			t.Errorf("MinimalDiff %v: got\n%s\nwant\n%s", minimal, got, want)
+		}
+	}
+}
+
+func TestRegionWordInBody(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) D() {}
+
+func (T) A() {
+	// region of memory to scan
+	x := 0
+	_ = x
+}
+
+func (T) C() {} // region C
+
+func (T) B() {}
+`
+	const want = `package p
+
+type T struct{}
+
+func (T) A() {
+	// region of memory to scan
+	x := 0
+	_ = x
+}
+
+func (T) B() {}
+
+func (T) C() {} // region C
+
+func (T) D() {}
+`
+	got := process(t, src, DefaultOptions())
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if second := process(t, got, DefaultOptions()); second != got {
+		t.Errorf("second run gives\n%s", second)
+	}
+}
+
+// benchmarkSource returns a file declaring n documented methods on a few
+// types, in reverse order.
+func benchmarkSource(n int) []byte {
+	var b strings.Builder
+	b.WriteString("package p\n\ntype A struct{}\ntype B struct{}\ntype C struct{}\n")
+	for i := n; i > 0; i-- {
+		recv := string(rune('A' + i%3))
+		fmt.Fprintf(&b, "\n// M%05d returns its number.\n//\n// It is here to be sorted.\nfunc (r *%s) M%05d() int {\n\tx := %d\n\treturn x // the number\n}\n", i, recv, i, i)
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkProcess(b *testing.B) {
+	src := benchmarkSource(3000)
+	for _, noComments := range []bool{false, true} {
+		name := "comments"
+		if noComments {
+			name = "no-comments"
+		}
+		b.Run(name, func(b *testing.B) {
+			opts := DefaultOptions()
+			opts.NoComments = noComments
+			b.SetBytes(int64(len(src)))
+			for b.Loop() {
+				if _, err := Process(src, "bench.go", opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestSharedDoc(t *testing.T) {
+	const src = `package p
+
+type P struct{}
+type Q struct{}
+
+// Y and X move together.
+func (P) Y() {}
+func (P) X() {}
+
+// W is on another type, so it is not joined.
+func (P) W() {}
+func (Q) V() {}
+
+// Z has its own doc.
+func (P) Z() {}
+// A has its own doc too.
+func (P) A() {}
+`
+	got := funcOrder(t, src, DefaultOptions())
+	want := []string{"A", "W", "Y", "X", "Z", "V"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
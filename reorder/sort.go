@@ -0,0 +1,333 @@
+package reorder
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	SortByName       = "name"
+	SortByPosition   = "position"
+	SortByVisibility = "visibility"
+	SortByLength     = "length"
+	// SortByCaller is experimental. It ignores Reverse.
+	SortByCaller = "caller"
+	// SortByAnnotation orders methods by their //order:N directive.
+	SortByAnnotation = "annotation"
+	// SortByFieldOrder follows the fields of the receiver struct. It needs
+	// PackageInfo.Fields, is experimental and ignores Reverse.
+	SortByFieldOrder = "field-order"
+)
+
+// SortModes lists the accepted values of Options.SortMode.
+var SortModes = []string{SortByName, SortByPosition, SortByVisibility, SortByLength, SortByCaller, SortByAnnotation, SortByFieldOrder}
+
+// ValidSortMode reports whether mode is one of SortModes or names a
+// registered comparator.
+func ValidSortMode(mode string) bool {
+	if _, ok := comparator(mode); ok {
+		return true
+	}
+	return slices.Contains(SortModes, mode)
+}
+
+const (
+	GroupOrderName        = "name"
+	GroupOrderDeclaration = "declaration"
+)
+
+// GroupOrders lists the accepted values of Options.GroupOrder.
+var GroupOrders = []string{GroupOrderName, GroupOrderDeclaration}
+
+// ConventionStringerFirst pins the methods of the common formatting and
+// encoding interfaces to the front: fmt.Stringer, fmt.GoStringer, error,
+// fmt.Formatter, then the json, text, binary and YAML marshalers, each
+// marshaler before its unmarshaler.
+const ConventionStringerFirst = "stringer-first"
+
+// Conventions maps each built-in convention to the method names it puts
+// first, in order. They are ready-made Options.Priority lists.
+var Conventions = map[string][]string{
+	ConventionStringerFirst: {
+		"String", "GoString", "Error", "Format",
+		"MarshalJSON", "UnmarshalJSON",
+		"MarshalText", "UnmarshalText",
+		"MarshalBinary", "UnmarshalBinary",
+		"MarshalYAML", "UnmarshalYAML",
+	},
+}
+
+type ByName []Method
+
+func (m ByName) Len() int           { return len(m) }
+func (m ByName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m ByName) Less(i, j int) bool { return m[i].decl.Name.Name < m[j].decl.Name.Name }
+
+// ByFoldedName orders methods by name ignoring case, so apple sorts
+// before Zebra. Names differing only in case fall back to byte order.
+type ByFoldedName []Method
+
+func (m ByFoldedName) Len() int      { return len(m) }
+func (m ByFoldedName) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m ByFoldedName) Less(i, j int) bool {
+	ni, nj := m[i].decl.Name.Name, m[j].decl.Name.Name
+	if li, lj := strings.ToLower(ni), strings.ToLower(nj); li != lj {
+		return li < lj
+	}
+	return ni < nj
+}
+
+type ByPos []Method
+
+func (m ByPos) Len() int           { return len(m) }
+func (m ByPos) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m ByPos) Less(i, j int) bool { return m[i].start < m[j].start }
+
+// ByVisibility orders exported methods before unexported ones, then by name.
+type ByVisibility []Method
+
+func (m ByVisibility) Len() int      { return len(m) }
+func (m ByVisibility) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m ByVisibility) Less(i, j int) bool {
+	ei, ej := ast.IsExported(m[i].decl.Name.Name), ast.IsExported(m[j].decl.Name.Name)
+	if ei != ej {
+		return ei
+	}
+	return m[i].decl.Name.Name < m[j].decl.Name.Name
+}
+
+// ByLength orders shorter methods first, by number of source lines
+// including the doc comment, then by name.
+type ByLength []Method
+
+func (m ByLength) Len() int      { return len(m) }
+func (m ByLength) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m ByLength) Less(i, j int) bool {
+	if m[i].lines != m[j].lines {
+		return m[i].lines < m[j].lines
+	}
+	return m[i].decl.Name.Name < m[j].decl.Name.Name
+}
+
+// ByAnnotation orders methods with an //order:N directive by ascending N,
+// then the methods without one by name.
+type ByAnnotation []Method
+
+func (m ByAnnotation) Len() int      { return len(m) }
+func (m ByAnnotation) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m ByAnnotation) Less(i, j int) bool {
+	if m[i].ordered != m[j].ordered {
+		return m[i].ordered
+	}
+	if m[i].ordered && m[i].order != m[j].order {
+		return m[i].order < m[j].order
+	}
+	return m[i].decl.Name.Name < m[j].decl.Name.Name
+}
+
+// ByAccessor orders methods by name with a leading Get or Set removed, so
+// GetFoo and SetFoo sort next to each other, the getter first. Methods
+// without such a prefix sort by their full name.
+type ByAccessor []Method
+
+func (m ByAccessor) Len() int      { return len(m) }
+func (m ByAccessor) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m ByAccessor) Less(i, j int) bool {
+	bi, ri := accessorKey(m[i].decl.Name.Name)
+	bj, rj := accessorKey(m[j].decl.Name.Name)
+	if bi != bj {
+		return bi < bj
+	}
+	return ri < rj
+}
+
+// ByFoldedAccessor is ByAccessor comparing the names without their Get
+// or Set prefix case-insensitively, like ByFoldedName.
+type ByFoldedAccessor []Method
+
+func (m ByFoldedAccessor) Len() int      { return len(m) }
+func (m ByFoldedAccessor) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m ByFoldedAccessor) Less(i, j int) bool {
+	bi, ri := accessorKey(m[i].decl.Name.Name)
+	bj, rj := accessorKey(m[j].decl.Name.Name)
+	if li, lj := strings.ToLower(bi), strings.ToLower(bj); li != lj {
+		return li < lj
+	}
+	if bi != bj {
+		return bi < bj
+	}
+	return ri < rj
+}
+
+// accessorKey splits a Get/Set prefix off name. The rank orders
+// plain names before getters before setters sharing the same base.
+func accessorKey(name string) (base string, rank int) {
+	for i, prefix := range []string{"Get", "Set"} {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if ok && rest != "" && ast.IsExported(rest) {
+			return rest, i + 1
+		}
+	}
+	return name, 0
+}
+
+// ByPriority orders methods listed in the priority list first, in the
+// listed order. Methods not listed compare equal, so a stable sort keeps
+// whatever order they already had.
+type ByPriority struct {
+	Methods []Method
+	Rank    map[string]int
+}
+
+func NewByPriority(methods []Method, priority []string) ByPriority {
+	rank := make(map[string]int, len(priority))
+	for i, name := range priority {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
+	}
+	return ByPriority{Methods: methods, Rank: rank}
+}
+
+func (p ByPriority) Len() int      { return len(p.Methods) }
+func (p ByPriority) Swap(i, j int) { p.Methods[i], p.Methods[j] = p.Methods[j], p.Methods[i] }
+func (p ByPriority) Less(i, j int) bool {
+	return p.rank(p.Methods[i]) < p.rank(p.Methods[j])
+}
+
+func (p ByPriority) rank(m Method) int {
+	if r, ok := p.Rank[m.decl.Name.Name]; ok {
+		return r
+	}
+	return len(p.Rank)
+}
+
+// sortMethods orders methods in place according to opts.
+// Sorting is stable so that methods comparing equal keep their original
+// relative order and repeated runs produce the same output.
+func sortMethods(methods []Method, opts Options) {
+	switch opts.SortMode {
+	case SortByCaller:
+		sortByCaller(methods)
+	case SortByFieldOrder:
+		sortByFieldOrder(methods, opts.Package.Fields)
+	default:
+		sort.Stable(sortInterface(methods, opts))
+	}
+
+	// Priority methods go first, the rest keep the order above
+	if len(opts.Priority) > 0 {
+		sort.Stable(NewByPriority(methods, opts.Priority))
+	}
+	sinkDeprecated(methods)
+}
+
+// sinkDeprecated moves deprecated methods after the others, keeping the
+// relative order within both.
+func sinkDeprecated(methods []Method) {
+	sort.SliceStable(methods, func(i, j int) bool {
+		return !methods[i].deprecated && methods[j].deprecated
+	})
+}
+
+// sortInterface returns the sort.Interface for the selected sort mode,
+// reversed if requested.
+func sortInterface(methods []Method, opts Options) sort.Interface {
+	var by sort.Interface
+	switch opts.SortMode {
+	case SortByPosition:
+		by = ByPos(methods)
+	case SortByVisibility:
+		by = ByVisibility(methods)
+	case SortByLength:
+		by = ByLength(methods)
+	case SortByAnnotation:
+		by = ByAnnotation(methods)
+	default:
+		if less, ok := comparator(opts.SortMode); ok {
+			by = byComparator{methods: methods, less: less}
+		} else if opts.PairAccessors && opts.FoldCase {
+			by = ByFoldedAccessor(methods)
+		} else if opts.PairAccessors {
+			by = ByAccessor(methods)
+		} else if opts.FoldCase {
+			by = ByFoldedName(methods)
+		} else {
+			by = ByName(methods)
+		}
+	}
+
+	if opts.Reverse {
+		by = sort.Reverse(by)
+	}
+	return by
+}
+
+// sortGrouped buckets methods by receiver type, orders the buckets
+// alphabetically, or by typeOrder if it is set, and sorts each bucket
+// with sortMethods, using opts.WithinGroup if set. Buckets that
+// implement ifaceOrder are then put in the interface's method order.
+func sortGrouped(methods []Method, opts Options, ifaceOrder, typeOrder []string) []Method {
+	groups := make(map[string][]Method)
+	var recvs []string
+	for _, m := range methods {
+		if _, ok := groups[m.recv]; !ok {
+			recvs = append(recvs, m.recv)
+		}
+		groups[m.recv] = append(groups[m.recv], m)
+	}
+	sort.Strings(recvs)
+	if typeOrder != nil {
+		// Types declared elsewhere keep alphabetical order after the
+		// known ones
+		rank := func(recv string) int {
+			if i := slices.Index(typeOrder, recv); i >= 0 {
+				return i
+			}
+			return len(typeOrder)
+		}
+		sort.SliceStable(recvs, func(i, j int) bool {
+			return rank(recvs[i]) < rank(recvs[j])
+		})
+	}
+
+	groupOpts := opts
+	if opts.WithinGroup != "" {
+		groupOpts.SortMode = opts.WithinGroup
+	}
+
+	sorted := make([]Method, 0, len(methods))
+	for _, recv := range recvs {
+		group := groups[recv]
+		sortMethods(group, groupOpts)
+		if implements(group, ifaceOrder, opts.Package) {
+			sort.Stable(NewByPriority(group, ifaceOrder))
+			sinkDeprecated(group)
+		}
+		// Constructors open their type's group
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].ctor && !group[j].ctor
+		})
+		sorted = append(sorted, group...)
+	}
+	return sorted
+}
+
+// declaredTypes returns the names of the types declared in file, in
+// source order.
+func declaredTypes(file *ast.File) []string {
+	names := []string{}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			names = append(names, spec.(*ast.TypeSpec).Name.Name)
+		}
+	}
+	return names
+}
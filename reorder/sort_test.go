@@ -0,0 +1,229 @@
+package reorder
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// funcOrder processes src with opts and returns the names of the
+// functions and methods in the result, in order.
+func funcOrder(t *testing.T, src string, opts Options) []string {
+	t.Helper()
+	res, err := Process([]byte(src), "test.go", opts)
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "test.go", res.Output, parser.SkipObjectResolution)
+	if err != nil {
+		t.Fatalf("result does not parse: %v\n%s", err, res.Output)
+	}
+	var names []string
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			names = append(names, fn.Name.Name)
+		}
+	}
+	return names
+}
+
+func TestSortAccessors(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) SetUid() {}
+func (T) GetURL() {}
+func (T) GetUid() {}
+func (T) Apple()  {}
+func (T) SetURL() {}
+`
+	tests := []struct {
+		name       string
+		pair, fold bool
+		want       []string
+	}{
+		{"name", false, false, []string{"Apple", "GetURL", "GetUid", "SetURL", "SetUid"}},
+		{"pair accessors", true, false, []string{"Apple", "GetURL", "SetURL", "GetUid", "SetUid"}},
+		{"fold case", false, true, []string{"Apple", "GetUid", "GetURL", "SetUid", "SetURL"}},
+		{"pair accessors and fold case", true, true, []string{"Apple", "GetUid", "SetUid", "GetURL", "SetURL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.PairAccessors, opts.FoldCase = tt.pair, tt.fold
+			if got := funcOrder(t, src, opts); !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStableOrder(t *testing.T) {
+	const src = `package p
+
+type A struct{}
+type B struct{}
+type C struct{}
+
+func (C) String() string { return "" }
+func (A) String() string { return "" }
+func (B) String() string { return "" }
+func (B) Close()         {}
+func (A) Close()         {}
+`
+	opts := DefaultOptions()
+	opts.GroupByReceiver = false
+	first := process(t, src, opts)
+
+	// Equal names keep their source order
+	want := []string{"(B) Close", "(A) Close", "(C) String", "(A) String", "(B) String"}
+	for i := 1; i < len(want); i++ {
+		if strings.Index(first, want[i-1]) > strings.Index(first, want[i]) {
+			t.Errorf("%s sorts after %s:\n%s", want[i-1], want[i], first)
+		}
+	}
+	if second := process(t, first, opts); second != first {
+		t.Errorf("second run gives\n%s\nafter\n%s", second, first)
+	}
+}
+
+func TestPairAccessors(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) SetName() {}
+func (T) Close()   {}
+func (T) GetAge()  {}
+func (T) Get()     {}
+func (T) GetName() {}
+func (T) Name()    {}
+func (T) SetAge()  {}
+`
+	opts := DefaultOptions()
+	opts.PairAccessors = true
+	got := funcOrder(t, src, opts)
+	want := []string{"GetAge", "SetAge", "Close", "Get", "Name", "GetName", "SetName"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) b() {}
+
+func (T) C() {}
+
+func (T) A() {
+	println()
+}
+`
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{SortByName, []string{"b", "C", "A"}},
+		{SortByVisibility, []string{"b", "C", "A"}},
+		{SortByLength, []string{"A", "b", "C"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.SortMode, opts.Reverse = tt.mode, true
+			if got := funcOrder(t, src, opts); !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoldCase(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+func (T) Zebra() {}
+func (T) apple() {}
+func (T) Mango() {}
+func (T) mango() {}
+func (T) banana() {}
+`
+	opts := DefaultOptions()
+	opts.FoldCase = true
+	got := funcOrder(t, src, opts)
+	want := []string{"apple", "banana", "Mango", "mango", "Zebra"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestWithinGroupVisibility(t *testing.T) {
+	const src = `package p
+
+type B struct{}
+type A struct{}
+
+func (B) reset() {}
+func (A) close() {}
+func (B) Write() {}
+func (A) Open()  {}
+func (B) Flush() {}
+func (A) Read()  {}
+`
+	opts := DefaultOptions()
+	opts.WithinGroup = SortByVisibility
+	got := funcOrder(t, src, opts)
+	want := []string{"Open", "Read", "close", "Flush", "Write", "reset"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestDeprecatedLast(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+// Old does it the old way.
+//
+// Deprecated: Use New.
+func (T) Old() {}
+
+func (T) Zoom() {}
+
+// Ancient is older still.
+//
+// Deprecated: Use Zoom.
+func (T) Ancient() {}
+
+func (T) Back() {}
+
+// Deprecation explains, but is not deprecated.
+func (T) Deprecation() {}
+`
+	tests := []struct {
+		name string
+		last bool
+		want []string
+	}{
+		{"off", false, []string{"Ancient", "Back", "Deprecation", "Old", "Zoom"}},
+		{"on", true, []string{"Back", "Deprecation", "Zoom", "Ancient", "Old"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.DeprecatedLast = tt.last
+			if got := funcOrder(t, src, opts); !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
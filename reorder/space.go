@@ -0,0 +1,177 @@
+package reorder
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"slices"
+)
+
+// trimTrailingSpace removes spaces and tabs from the end of every line of
+// src. Lines that end inside a raw string literal are left alone, since
+// their trailing blanks are part of the string's value.
+func trimTrailingSpace(src []byte) []byte {
+	raw := rawStringSpans(src)
+
+	var out bytes.Buffer
+	for off := 0; off < len(src); {
+		line, next := nextLine(src, off)
+		body, cr := bytes.CutSuffix(line, []byte("\r"))
+		trimmed := bytes.TrimRight(body, " \t")
+		if inSpans(raw, off+len(trimmed)) {
+			trimmed = body
+		}
+		out.Write(trimmed)
+		if cr {
+			out.WriteByte('\r')
+		}
+		if next > off+len(line) {
+			out.WriteByte('\n')
+		}
+		off = next
+	}
+	return out.Bytes()
+}
+
+// rawStringSpans returns the byte offsets [start, end) of the raw string
+// literals in src.
+func rawStringSpans(src []byte) [][2]int {
+	fSet := token.NewFileSet()
+	file := fSet.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+
+	var spans [][2]int
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return spans
+		}
+		// The scanner drops carriage returns from raw strings, so find
+		// the closing quote in src rather than using the literal's length
+		if tok == token.STRING && lit[0] == '`' {
+			start := file.Offset(pos)
+			end := start + 1 + bytes.IndexByte(src[start+1:], '`') + 1
+			spans = append(spans, [2]int{start, end})
+		}
+	}
+}
+
+func inSpans(spans [][2]int, off int) bool {
+	for _, span := range spans {
+		if off > span[0] && off < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// spaceGroups puts n blank lines between two of methods that start
+// different receiver groups and are separated only by blank lines in
+// src, formatted source in which gofmt collapsed the separators written
+// by the reassembly.
+func spaceGroups(src []byte, filename string, methods []Method, grouped bool, n int) []byte {
+	// Group of each reordered declaration, by receiver and name
+	recvOf := make(map[[2]string]string, len(methods))
+	for _, m := range methods {
+		recvOf[declKey(m.decl)] = m.recv
+		for _, joined := range m.joined {
+			recvOf[declKey(joined)] = m.recv
+		}
+	}
+
+	return respace(src, filename, func(prev, next *ast.FuncDecl) int {
+		prevRecv, ok1 := recvOf[declKey(prev)]
+		nextRecv, ok2 := recvOf[declKey(next)]
+		if !ok1 || !ok2 || prevRecv == nextRecv || !grouped && prevRecv != "" {
+			return 0
+		}
+		return n
+	})
+}
+
+// spaceSections puts back the blank lines that ended each section of
+// Options.PreserveSections in orig into src, formatted source in which
+// gofmt collapsed them. methods are in source order, and sectionStart
+// marks the first method of each section but the first, as returned by
+// scopes.
+func spaceSections(orig, src []byte, fSet *token.FileSet, filename string, methods []Method, sectionStart map[int]bool) []byte {
+	// Section of each reordered declaration, and the blank lines that
+	// preceded each section in orig
+	sectionOf := make(map[[2]string]int, len(methods))
+	var blanks []int
+	for i, m := range methods {
+		if sectionStart[i] {
+			gap := orig[fSet.Position(methods[i-1].end).Offset:fSet.Position(m.start).Offset]
+			blanks = append(blanks, bytes.Count(gap, []byte("\n"))-1)
+		}
+		sectionOf[declKey(m.decl)] = len(blanks)
+		for _, joined := range m.joined {
+			sectionOf[declKey(joined)] = len(blanks)
+		}
+	}
+
+	return respace(src, filename, func(prev, next *ast.FuncDecl) int {
+		prevSection, ok1 := sectionOf[declKey(prev)]
+		nextSection, ok2 := sectionOf[declKey(next)]
+		if !ok1 || !ok2 || nextSection != prevSection+1 {
+			return 0
+		}
+		return blanks[prevSection]
+	})
+}
+
+// respace parses src and, between every two adjacent functions separated
+// only by blank lines, puts as many blank lines as blanks returns for
+// them. Pairs for which it returns 0 are left alone.
+func respace(src []byte, filename string, blanks func(prev, next *ast.FuncDecl) int) []byte {
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return src
+	}
+
+	type edit struct{ start, end, n int }
+	var edits []edit
+	for i := 1; i < len(file.Decls); i++ {
+		prev, ok1 := file.Decls[i-1].(*ast.FuncDecl)
+		next, ok2 := file.Decls[i].(*ast.FuncDecl)
+		if !ok1 || !ok2 {
+			continue
+		}
+		n := blanks(prev, next)
+		if n <= 0 {
+			continue
+		}
+
+		// From the end of prev's last line to the start of next's doc
+		end := fSet.Position(prev.End()).Offset
+		if i := bytes.IndexByte(src[end:], '\n'); i >= 0 {
+			end += i
+		}
+		start := next.Pos()
+		if next.Doc != nil {
+			start = next.Doc.Pos()
+		}
+		if off := fSet.Position(start).Offset; end < off && len(bytes.TrimSpace(src[end:off])) == 0 {
+			edits = append(edits, edit{end, off, n})
+		}
+	}
+
+	out := slices.Clone(src)
+	for _, e := range slices.Backward(edits) {
+		out = slices.Replace(out, e.start, e.end, bytes.Repeat([]byte("\n"), e.n+1)...)
+	}
+	return out
+}
+
+// declKey identifies a declaration by its receiver type and name.
+func declKey(decl *ast.FuncDecl) [2]string {
+	var recv string
+	if decl.Recv != nil {
+		recv = receiverType(decl.Recv)
+	}
+	return [2]string{recv, decl.Name.Name}
+}
@@ -0,0 +1,58 @@
+package reorder
+
+import (
+	"testing"
+)
+
+func TestTrimTrailingSpace(t *testing.T) {
+	const src = "package p\n\ntype T struct{}\n\nfunc (T) B() {  \n\tx := 1 \t\n\t_ = x\n}\n  \nfunc (T) A() string {\n\treturn `raw  \nkept  `\n}\n"
+	const want = "package p\n\ntype T struct{}\n\nfunc (T) A() string {\n\treturn `raw  \nkept  `\n}\n\nfunc (T) B() {\n\tx := 1\n\t_ = x\n}\n"
+	opts := DefaultOptions()
+	opts.Format, opts.TrimTrailingSpace = false, true
+	if got := process(t, src, opts); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGroupBlankLines(t *testing.T) {
+	const src = `package p
+
+type A struct{}
+type B struct{}
+
+func (B) Y() {}
+
+// X documents X.
+func (A) X() {}
+
+func (B) Z() {}
+
+func (A) W() {}
+`
+	const want = `package p
+
+type A struct{}
+type B struct{}
+
+func (A) W() {}
+
+// X documents X.
+func (A) X() {}
+
+
+func (B) Y() {}
+
+func (B) Z() {}
+`
+	for _, format := range []bool{true, false} {
+		opts := DefaultOptions()
+		opts.Format, opts.GroupBlankLines = format, 2
+		got := process(t, src, opts)
+		if got != want {
+			t.Errorf("Format %v: got\n%s\nwant\n%s", format, got, want)
+		}
+		if again := process(t, got, opts); again != got {
+			t.Errorf("Format %v: second run gives\n%s", format, again)
+		}
+	}
+}
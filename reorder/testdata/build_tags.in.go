@@ -0,0 +1,11 @@
+//go:build linux && !cgo
+// +build linux,!cgo
+
+// Package p is only built on Linux without cgo.
+package p
+
+type T struct{}
+
+func (T) B() {}
+
+func (T) A() {}
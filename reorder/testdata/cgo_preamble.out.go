@@ -0,0 +1,16 @@
+package testdata
+
+// #cgo CFLAGS: -O2
+//#include <stdio.h>
+//   static int   add(int a,int b){return a+b;}
+//	// indented with a tab
+/*
+  #define X 1
+*/
+import "C"
+
+type T struct{}
+
+func (T) A() {}
+
+func (T) B() {}
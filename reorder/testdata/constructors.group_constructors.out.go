@@ -0,0 +1,17 @@
+package testdata
+
+type Server struct{}
+
+func listen() {}
+
+// NewWorker is a method, but its New prefix keeps it in place as well.
+func (s *Server) NewWorker() {}
+
+// NewServer moves before the methods of Server with GroupConstructors.
+func NewServer() *Server { return &Server{} }
+
+func (s *Server) Accept() {}
+
+func (s *Server) Handle() {}
+
+func (s *Server) Stop() {}
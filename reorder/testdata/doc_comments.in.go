@@ -0,0 +1,16 @@
+package testdata
+
+type Cache struct{}
+
+// Set stores v under k.
+func (c *Cache) Set(k, v string) {}
+
+// Get returns the value stored under k.
+//
+// Multi-paragraph doc comments move with their method.
+func (c *Cache) Get(k string) string { return "" }
+
+/*
+Delete removes k.
+*/
+func (c *Cache) Delete(k string) {}
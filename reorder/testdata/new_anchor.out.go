@@ -0,0 +1,9 @@
+package testdata
+
+type Thing struct{}
+
+func (t *Thing) Bar() {}
+
+func (t *Thing) NewThing() *Thing { return &Thing{} }
+
+func (t *Thing) Zap() {}
@@ -0,0 +1,5 @@
+package testdata
+
+const answer = 42
+
+func helper() int { return answer }
@@ -0,0 +1,17 @@
+package testdata
+
+type Point struct{ x, y int }
+
+// Add returns the sum of p and q.
+func (p Point) Add(q Point) Point { return Point{p.x + q.x, p.y + q.y} }
+
+func (p Point) Neg() Point { return p.Scale(-1) }
+
+func (p Point) Scale(k int) Point { return Point{p.x * k, p.y * k} }
+
+// String formats the point.
+func (p Point) String() string { return "" }
+
+// X and Y return the coordinates.
+func (p Point) X() int { return p.x }
+func (p Point) Y() int { return p.y }
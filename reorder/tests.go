@@ -0,0 +1,93 @@
+package reorder
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// testPrefixes start the names of the functions go test runs.
+var testPrefixes = []string{"Test", "Benchmark", "Fuzz", "Example"}
+
+// SymbolOrder returns the functions, types and methods declared in src,
+// in source order, for use as Options.TestOrder. A method M of type T is
+// listed as T_M, the way tests for it are conventionally named.
+func SymbolOrder(src []byte, filename string) ([]string, error) {
+	fSet := token.NewFileSet()
+	file, err := parser.ParseFile(fSet, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file %s: %w", filename, err)
+	}
+
+	var names []string
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil {
+				names = append(names, receiverType(decl.Recv)+"_"+decl.Name.Name)
+			} else {
+				names = append(names, decl.Name.Name)
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				names = append(names, spec.(*ast.TypeSpec).Name.Name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// testRank returns the position in order of the symbol a test function
+// is named after: TestFoo and TestFoo_empty both go with Foo, and the
+// longest matching symbol wins. ok is false for other functions.
+func testRank(name string, order []string) (rank int, ok bool) {
+	var rest string
+	for _, prefix := range testPrefixes {
+		if r, found := strings.CutPrefix(name, prefix); found {
+			rest = strings.TrimPrefix(r, "_")
+			break
+		}
+	}
+	if rest == "" {
+		return 0, false
+	}
+
+	best := -1
+	for i, symbol := range order {
+		matches := rest == symbol || strings.HasPrefix(rest, symbol+"_")
+		if matches && (best < 0 || len(symbol) > len(order[best])) {
+			best = i
+		}
+	}
+	return best, best >= 0
+}
+
+// sortByTestOrder orders test functions like the symbols in order they
+// are named after, then everything else by name.
+func sortByTestOrder(funcs []Method, order []string) {
+	type key struct {
+		rank int
+		ok   bool
+	}
+	keys := make(map[*ast.FuncDecl]key, len(funcs))
+	for _, m := range funcs {
+		rank, ok := testRank(m.decl.Name.Name, order)
+		keys[m.decl] = key{rank, ok}
+	}
+	sort.SliceStable(funcs, func(i, j int) bool {
+		ki, kj := keys[funcs[i].decl], keys[funcs[j].decl]
+		if ki.ok != kj.ok {
+			return ki.ok
+		}
+		if ki.ok && ki.rank != kj.rank {
+			return ki.rank < kj.rank
+		}
+		return funcs[i].decl.Name.Name < funcs[j].decl.Name.Name
+	})
+}
@@ -0,0 +1,52 @@
+package reorder
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTestOrder(t *testing.T) {
+	const code = `package p
+
+func Parse() {}
+
+type Server struct{}
+
+func (s *Server) Start() {}
+
+func Format() {}
+`
+	const tests = `package p
+
+import "testing"
+
+func TestFormat(t *testing.T) {}
+
+func TestHelperThing(t *testing.T) {}
+
+func BenchmarkParse(b *testing.B) {}
+
+func TestServer_Start_twice(t *testing.T) {}
+
+func helper() {}
+
+func TestParse_empty(t *testing.T) {}
+
+func TestServer(t *testing.T) {}
+`
+	order, err := SymbolOrder([]byte(code), "p.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Parse", "Server", "Server_Start", "Format"}; !slices.Equal(order, want) {
+		t.Fatalf("SymbolOrder() = %v, want %v", order, want)
+	}
+
+	opts := DefaultOptions()
+	opts.IncludeFunctions, opts.TestOrder = true, order
+	got := funcOrder(t, tests, opts)
+	want := []string{"BenchmarkParse", "TestParse_empty", "TestServer", "TestServer_Start_twice", "TestFormat", "TestHelperThing", "helper"}
+	if !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}